@@ -0,0 +1,272 @@
+package virtualbox
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var reAttachmentSlot = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+// BuildError is returned by Builder.Build when a provisioning step fails. Err
+// is the error of the failed step and Rollback holds the errors that occurred
+// while undoing the previous steps, if any.
+type BuildError struct {
+	Step     string
+	Err      error
+	Rollback []error
+}
+
+func (e *BuildError) Error() string {
+	s := fmt.Sprintf("%s: %v", e.Step, e.Err)
+	if len(e.Rollback) > 0 {
+		rs := make([]string, len(e.Rollback))
+		for i, err := range e.Rollback {
+			rs[i] = err.Error()
+		}
+		s += fmt.Sprintf(" (rollback failed: %s)", strings.Join(rs, "; "))
+	}
+	return s
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+type buildStep struct {
+	name string
+	run  func(m *Machine) (undo func() error, err error)
+}
+
+// Builder creates and provisions a new machine as a single transaction: if any
+// step fails, everything created so far is removed again. Steps run in the
+// order they are added, after the machine itself has been created.
+//
+//	hn := &HostonlyNet{IPv4: ...}
+//	m, err := NewBuilder("web", "").
+//		HostonlyNet(hn).
+//		HardDisk("/vms/web/web.vdi", 10240).
+//		StorageCtl("SATA", StorageController{SysBus: SysBusSATA, Bootable: true}).
+//		AttachStorage("SATA", StorageMedium{DriveType: DriveHDD, Medium: "/vms/web/web.vdi"}).
+//		Step("attach host-only NIC", func(m *Machine) error {
+//			return m.SetNIC(2, NIC{Network: NICNetHostonly, HostonlyAdapter: hn.Name})
+//		}).
+//		Build()
+type Builder struct {
+	Name       string
+	BaseFolder string
+	Groups     []string
+
+	steps   []buildStep
+	created map[string]bool // disk images created by the builder
+}
+
+// NewBuilder returns a Builder for a machine with the given name, base folder
+// (empty for the default) and groups.
+func NewBuilder(name, basefolder string, groups ...string) *Builder {
+	return &Builder{Name: name, BaseFolder: basefolder, Groups: groups}
+}
+
+func (b *Builder) add(name string, run func(m *Machine) (func() error, error)) *Builder {
+	b.steps = append(b.steps, buildStep{name, run})
+	return b
+}
+
+// Step adds a custom provisioning step. Anything it creates outside the
+// machine is not rolled back.
+func (b *Builder) Step(name string, f func(m *Machine) error) *Builder {
+	return b.add(name, func(m *Machine) (func() error, error) {
+		return nil, f(m)
+	})
+}
+
+// HostonlyNet creates a host-only network interface, configures it with the
+// settings of n and stores the name of the new interface in n.Name.
+func (b *Builder) HostonlyNet(n *HostonlyNet) *Builder {
+	return b.add("create host-only network", func(m *Machine) (func() error, error) {
+		created, err := CreateHostonlyNet()
+		if err != nil {
+			return nil, err
+		}
+		n.Name = created.Name
+		return created.Remove, n.Config()
+	})
+}
+
+// HardDisk creates a disk image of the given size in MB. It is deleted on
+// rollback unless the machine deletion already removed it.
+func (b *Builder) HardDisk(filename string, size uint) *Builder {
+	return b.add(fmt.Sprintf("create hard disk %s", filename), func(m *Machine) (func() error, error) {
+		if err := CreateHardDisk(filename, size); err != nil {
+			return nil, err
+		}
+		if abs, err := filepath.Abs(filename); err == nil {
+			if b.created == nil {
+				b.created = map[string]bool{}
+			}
+			b.created[abs] = true
+		}
+		return func() error {
+			if !Exists(filename) {
+				return nil
+			}
+			return CloseMedium(DriveHDD, filename, true)
+		}, nil
+	})
+}
+
+// Modify lets f change the machine settings and applies them with
+// Machine.Modify.
+func (b *Builder) Modify(f func(m *Machine)) *Builder {
+	return b.Step("modify machine", func(m *Machine) error {
+		f(m)
+		return m.Modify()
+	})
+}
+
+// StorageCtl adds a storage controller.
+func (b *Builder) StorageCtl(name string, ctl StorageController) *Builder {
+	return b.Step(fmt.Sprintf("add storage controller %q", name), func(m *Machine) error {
+		return m.AddStorageCtl(name, ctl)
+	})
+}
+
+// AttachStorage attaches a storage medium to a storage controller.
+func (b *Builder) AttachStorage(ctlName string, medium StorageMedium) *Builder {
+	return b.Step(fmt.Sprintf("attach %s", medium.Medium), func(m *Machine) error {
+		return m.AttachStorage(ctlName, medium)
+	})
+}
+
+// NIC sets the n-th NIC.
+func (b *Builder) NIC(n int, nic NIC) *Builder {
+	return b.Step(fmt.Sprintf("set NIC %d", n), func(m *Machine) error {
+		return m.SetNIC(n, nic)
+	})
+}
+
+// Spec applies a machine spec. The spec name is ignored.
+func (b *Builder) Spec(spec MachineSpec) *Builder {
+	return b.Step("apply spec", func(m *Machine) error {
+		spec.Name = m.Name
+		return m.Apply(spec)
+	})
+}
+
+// Build creates the machine and runs all steps. If a step fails, the machine
+// is deleted along with the disks the builder created, the other resources
+// created by the builder are removed in reverse order, and a *BuildError is
+// returned. Media attached with AttachStorage that the builder did not create
+// are detached and left alone.
+func (b *Builder) Build() (*Machine, error) {
+	m, err := CreateMachine(b.Name, b.BaseFolder, b.Groups...)
+	if err != nil {
+		return nil, &BuildError{Step: "create machine", Err: err}
+	}
+
+	var undo []func() error
+	for _, st := range b.steps {
+		u, err := st.run(m)
+		if u != nil {
+			undo = append(undo, u)
+		}
+		if err != nil {
+			return nil, &BuildError{Step: st.name, Err: err, Rollback: b.rollback(m, undo)}
+		}
+	}
+	if err := m.Refresh(); err != nil {
+		return nil, &BuildError{Step: "refresh machine", Err: err, Rollback: b.rollback(m, undo)}
+	}
+	return m, nil
+}
+
+// rollback deletes the machine first, as attached media and networks in use
+// cannot be removed, then runs the undo functions in reverse order. Media not
+// created by the builder are detached beforehand, since deleting the machine
+// deletes the disk images attached to it.
+func (b *Builder) rollback(m *Machine, undo []func() error) []error {
+	var errs []error
+	if err := m.Refresh(); err == nil {
+		if err := b.deleteMachine(m); err != nil {
+			errs = append(errs, fmt.Errorf("delete machine %s: %v", m.Name, err))
+		}
+	} else if err != ErrMachineNotExist {
+		errs = append(errs, fmt.Errorf("delete machine %s: %v", m.Name, err))
+	}
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (b *Builder) deleteMachine(m *Machine) error {
+	if err := m.Poweroff(); err != nil {
+		return err
+	}
+	out, err := vbmOut("showvminfo", m.id(), "--machinereadable")
+	if err != nil {
+		return err
+	}
+	vs, err := parseVMSettings(out)
+	if err != nil {
+		return err
+	}
+	for _, a := range vs.attachments() {
+		abs, err := filepath.Abs(a.medium)
+		if err == nil && b.created[abs] {
+			continue
+		}
+		err = vbm("storageattach", m.id(), "--storagectl", a.ctl,
+			"--port", fmt.Sprintf("%d", a.port), "--device", fmt.Sprintf("%d", a.device),
+			"--medium", "none")
+		if err != nil {
+			return fmt.Errorf("detach %s: %v", a.medium, err)
+		}
+	}
+	return vbm("unregistervm", m.id(), "--delete")
+}
+
+// attachment is a medium attached to a storage controller.
+type attachment struct {
+	ctl          string
+	port, device uint
+	medium       string
+}
+
+// attachments returns the media attached to the storage controllers, which
+// showvminfo reports as "<controller>-<port>-<device>"="<medium>".
+func (vs *vmSettings) attachments() []attachment {
+	var as []attachment
+	for i := 0; ; i++ {
+		ctl, ok := vs.vals[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			break
+		}
+		var keys []string
+		for key := range vs.vals {
+			if strings.HasPrefix(key, ctl+"-") {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			res := reAttachmentSlot.FindStringSubmatch(key[len(ctl)+1:])
+			if res == nil {
+				continue
+			}
+			switch medium := vs.vals[key]; medium {
+			case "none", "emptydrive", "":
+			default:
+				port, _ := strconv.ParseUint(res[1], 10, 32)
+				device, _ := strconv.ParseUint(res[2], 10, 32)
+				as = append(as, attachment{ctl, uint(port), uint(device), medium})
+			}
+		}
+	}
+	return as
+}
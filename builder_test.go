@@ -0,0 +1,118 @@
+package virtualbox
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeVBM mimics the VBoxManage commands used by Builder for a single machine
+// with a SATA controller. Like VBoxManage, "unregistervm --delete" deletes the
+// disk images still attached to the machine, after which showvminfo reports
+// the machine as missing.
+const fakeVBM = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$*" >> "$dir/log"
+case "$1" in
+createmedium)
+	touch "$4" ;;
+storageattach)
+	if [ "$9" = "--medium" ] && [ "${10}" = "none" ]; then
+		touch "$dir/detached-$6"
+	else
+		echo "$6=${12}" >> "$dir/attached"
+	fi ;;
+showvminfo)
+	if [ -f "$dir/deleted" ]; then
+		echo "VBoxManage: error: Could not find a registered machine with UUID {$2}" >&2
+		exit 1
+	fi
+	echo 'name="web"'
+	echo 'UUID="uuid"'
+	echo 'VMState="poweroff"'
+	echo 'storagecontrollername0="SATA"'
+	[ -f "$dir/attached" ] || exit 0
+	while IFS='=' read port medium; do
+		[ -f "$dir/detached-$port" ] && medium=none
+		echo "\"SATA-$port-0\"=\"$medium\""
+		echo "\"SATA-ImageUUID-$port-0\"=\"uuid-$port\""
+	done < "$dir/attached" ;;
+unregistervm)
+	touch "$dir/deleted"
+	[ -f "$dir/attached" ] || exit 0
+	while IFS='=' read port medium; do
+		[ -f "$dir/detached-$port" ] || rm -f "$medium"
+	done < "$dir/attached" ;;
+closemedium)
+	rm -f "$3" ;;
+esac
+`
+
+func TestBuildRollback(t *testing.T) {
+	dir := useFakeVBM(t, fakeVBM)
+
+	base := filepath.Join(dir, "base.vdi")
+	if err := ioutil.WriteFile(base, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	disk := filepath.Join(dir, "web.vdi")
+	errStep := errors.New("step failed")
+	_, err := NewBuilder("web", "").
+		HardDisk(disk, 1024).
+		AttachStorage("SATA", StorageMedium{Port: 0, DriveType: DriveHDD, Medium: base}).
+		AttachStorage("SATA", StorageMedium{Port: 1, DriveType: DriveHDD, Medium: disk}).
+		Step("fail", func(m *Machine) error { return errStep }).
+		Build()
+	if be, ok := err.(*BuildError); !ok || be.Err != errStep || len(be.Rollback) > 0 {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if !Exists(base) {
+		t.Errorf("base disk %s was deleted", base)
+	}
+	if Exists(disk) {
+		t.Errorf("created disk %s was not deleted", disk)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if !strings.HasPrefix(line, "list ") && !strings.HasPrefix(line, "showvminfo ") {
+			got = append(got, line)
+		}
+	}
+	want := []string{
+		"createvm --name web --register",
+		"createmedium disk --filename " + disk + " --size 1024",
+		"storageattach uuid --storagectl SATA --port 0 --device 0 --type hdd --medium " + base,
+		"storageattach uuid --storagectl SATA --port 1 --device 0 --type hdd --medium " + disk,
+		"storageattach uuid --storagectl SATA --port 0 --device 0 --medium none",
+		"unregistervm uuid --delete",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got commands\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestBuildRollbackDeleted(t *testing.T) {
+	useFakeVBM(t, fakeVBM)
+
+	errStep := errors.New("step failed")
+	_, err := NewBuilder("web", "").
+		Step("delete", func(m *Machine) error {
+			if err := m.Delete(); err != nil {
+				return err
+			}
+			return errStep
+		}).
+		Build()
+	if be, ok := err.(*BuildError); !ok || be.Err != errStep || len(be.Rollback) > 0 {
+		t.Fatalf("Build() error = %v", err)
+	}
+}
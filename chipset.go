@@ -0,0 +1,290 @@
+package virtualbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrIOMMUChipset = errors.New("IOMMU requires the ICH9 chipset")
+)
+
+var (
+	reChipsetNICLimit = regexp.MustCompile(`^Maximum (\S+) Network Adapter count$`)
+	reChipsetCtlLimit = regexp.MustCompile(`^Maximum (\S+) (\S+) Controllers$`)
+)
+
+// Chipset represents the emulated motherboard chipset of a machine.
+type Chipset string
+
+const (
+	ChipsetPIIX3 = Chipset("piix3")
+	ChipsetICH9  = Chipset("ich9")
+)
+
+// IOMMU represents the type of the emulated IOMMU. It requires the ICH9
+// chipset.
+type IOMMU string
+
+const (
+	IOMMUNone      = IOMMU("none")
+	IOMMUAutomatic = IOMMU("automatic")
+	IOMMUAMD       = IOMMU("amd")
+	IOMMUIntel     = IOMMU("intel")
+)
+
+// PointingDevice represents the emulated mouse of a machine.
+type PointingDevice string
+
+const (
+	PointingNone          = PointingDevice("none")
+	PointingPS2Mouse      = PointingDevice("ps2")
+	PointingUSBMouse      = PointingDevice("usb")
+	PointingUSBTablet     = PointingDevice("usbtablet")
+	PointingUSBMultiTouch = PointingDevice("usbmultitouch")
+)
+
+// KeyboardType represents the emulated keyboard of a machine.
+type KeyboardType string
+
+const (
+	KeyboardNone = KeyboardType("none")
+	KeyboardPS2  = KeyboardType("ps2")
+	KeyboardUSB  = KeyboardType("usb")
+)
+
+// parseInput sets the Mouse or Keyboard field from the hidpointing and
+// hidkeyboard keys of showvminfo, whose values differ from those accepted by
+// modifyvm. It returns false for other keys.
+func (m *Machine) parseInput(key, val string) bool {
+	switch key {
+	case "hidpointing":
+		switch val {
+		case "ps2mouse":
+			m.Mouse = PointingPS2Mouse
+		case "usbmouse":
+			m.Mouse = PointingUSBMouse
+		default:
+			m.Mouse = PointingDevice(val)
+		}
+	case "hidkeyboard":
+		switch val {
+		case "ps2kbd":
+			m.Keyboard = KeyboardPS2
+		case "usbkbd":
+			m.Keyboard = KeyboardUSB
+		default:
+			m.Keyboard = KeyboardType(val)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// ChipsetLimits holds the number of NICs and storage controllers per bus a
+// chipset supports, as reported by systemproperties.
+type ChipsetLimits struct {
+	NICs        uint
+	Controllers map[SystemBus]uint
+}
+
+// GetChipsetLimits returns the limits of the given chipset.
+func GetChipsetLimits(c Chipset) (*ChipsetLimits, error) {
+	out, err := SystemProperties()
+	if err != nil {
+		return nil, err
+	}
+	return parseChipsetLimits(out, c)
+}
+
+// systemproperties names some buses differently than storagectl.
+var chipsetLimitBuses = map[string]SystemBus{
+	"ide":         SysBusIDE,
+	"sata":        SysBusSATA,
+	"scsi":        SysBusSCSI,
+	"sas":         SysBusSAS,
+	"floppy":      SysBusFloppy,
+	"nvme":        SysBusPCIe,
+	"virtio-scsi": SysBusVirtIO,
+}
+
+func parseChipsetLimits(out string, c Chipset) (*ChipsetLimits, error) {
+	l := &ChipsetLimits{Controllers: map[SystemBus]uint{}}
+	found := false
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		res := reColonLine.FindStringSubmatch(s.Text())
+		if res == nil {
+			continue
+		}
+		key, val := res[1], strings.TrimSpace(res[2])
+		if r := reChipsetNICLimit.FindStringSubmatch(key); r != nil && strings.EqualFold(r[1], string(c)) {
+			n, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			l.NICs = uint(n)
+			found = true
+		} else if r := reChipsetCtlLimit.FindStringSubmatch(key); r != nil && strings.EqualFold(r[1], string(c)) {
+			bus, ok := chipsetLimitBuses[strings.ToLower(r[2])]
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseUint(val, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			l.Controllers[bus] = uint(n)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no limits reported for chipset %q", c)
+	}
+	return l, nil
+}
+
+// Check returns an error if the highest NIC slot in use or the number of
+// storage controllers per bus exceeds the limits.
+func (l *ChipsetLimits) Check(nic uint, ctls map[SystemBus]uint) error {
+	if nic > l.NICs {
+		return fmt.Errorf("NIC %d exceeds the chipset limit of %d NICs", nic, l.NICs)
+	}
+	for bus, n := range ctls {
+		if max, ok := l.Controllers[bus]; ok && n > max {
+			return fmt.Errorf("%d %s controllers exceed the chipset limit of %d", n, bus, max)
+		}
+	}
+	return nil
+}
+
+// storageCtlBuses maps the controller types reported by showvminfo to their
+// bus.
+var storageCtlBuses = map[string]SystemBus{
+	"piix3":       SysBusIDE,
+	"piix4":       SysBusIDE,
+	"ich6":        SysBusIDE,
+	"intelahci":   SysBusSATA,
+	"lsilogic":    SysBusSCSI,
+	"buslogic":    SysBusSCSI,
+	"lsilogicsas": SysBusSAS,
+	"i82078":      SysBusFloppy,
+	"nvme":        SysBusPCIe,
+	"virtioscsi":  SysBusVirtIO,
+}
+
+// deviceCounts returns the highest enabled NIC slot and the number of storage
+// controllers per bus in the settings reported by showvminfo. NIC slots are
+// fixed, so a chipset must provide the highest slot in use rather than just
+// as many NICs as are enabled.
+func (vs *vmSettings) deviceCounts() (nic uint, ctls map[SystemBus]uint) {
+	for i := 1; ; i++ {
+		val, ok := vs.vals[fmt.Sprintf("nic%d", i)]
+		if !ok {
+			break
+		}
+		if val != string(NICNetAbsent) {
+			nic = uint(i)
+		}
+	}
+	ctls = map[SystemBus]uint{}
+	for i := 0; ; i++ {
+		typ, ok := vs.vals[fmt.Sprintf("storagecontrollertype%d", i)]
+		if !ok {
+			break
+		}
+		if bus, ok := storageCtlBuses[strings.ToLower(typ)]; ok {
+			ctls[bus]++
+		}
+	}
+	return nic, ctls
+}
+
+// checkChipsetLimits returns an error if the devices of the machine together
+// with the given NIC slot (0 for none) and an additional storage controller on
+// bus (empty for none) exceed the limits of its chipset.
+func (m *Machine) checkChipsetLimits(nic uint, bus SystemBus) error {
+	c := m.Chipset
+	if c == "" {
+		c = ChipsetPIIX3
+	}
+	l, err := GetChipsetLimits(c)
+	if err != nil {
+		return err
+	}
+	out, err := vbmOut("showvminfo", m.id(), "--machinereadable")
+	if err != nil {
+		return err
+	}
+	vs, err := parseVMSettings(out)
+	if err != nil {
+		return err
+	}
+	n, ctls := vs.deviceCounts()
+	if nic > n {
+		n = nic
+	}
+	if bus != "" {
+		ctls[bus]++
+	}
+	return l.Check(n, ctls)
+}
+
+// SetChipset changes the chipset of the machine after checking that its NICs
+// and storage controllers fit the limits of the new chipset.
+func (m *Machine) SetChipset(c Chipset) error {
+	l, err := GetChipsetLimits(c)
+	if err != nil {
+		return err
+	}
+	out, err := vbmOut("showvminfo", m.id(), "--machinereadable")
+	if err != nil {
+		return err
+	}
+	vs, err := parseVMSettings(out)
+	if err != nil {
+		return err
+	}
+	if err := l.Check(vs.deviceCounts()); err != nil {
+		return fmt.Errorf("cannot switch to %s: %v", c, err)
+	}
+	if err := vbm("modifyvm", m.id(), "--chipset", string(c)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetIOMMU selects the IOMMU type. Any type but IOMMUNone requires the ICH9
+// chipset.
+func (m *Machine) SetIOMMU(t IOMMU) error {
+	if t != IOMMUNone && m.Chipset != ChipsetICH9 {
+		return ErrIOMMUChipset
+	}
+	if err := vbm("modifyvm", m.id(), "--iommu", string(t)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetPointingDevice selects the emulated mouse.
+func (m *Machine) SetPointingDevice(p PointingDevice) error {
+	if err := vbm("modifyvm", m.id(), "--mouse", string(p)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetKeyboard selects the emulated keyboard.
+func (m *Machine) SetKeyboard(k KeyboardType) error {
+	if err := vbm("modifyvm", m.id(), "--keyboard", string(k)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
@@ -0,0 +1,114 @@
+package virtualbox
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseChipsetLimits(t *testing.T) {
+	out := `Maximum guest RAM size:          2097152 Megabytes
+Maximum PIIX3 Network Adapter count: 8
+Maximum ICH9 Network Adapter count: 36
+Maximum PIIX3 IDE Controllers:   1
+Maximum ICH9 IDE Controllers:    1
+Maximum PIIX3 SATA Controllers:  1
+Maximum ICH9 SATA Controllers:   8
+Maximum ICH9 NVMe Controllers:   1
+Maximum ICH9 virtio-scsi Controllers: 1
+Maximum ICH9 USB Controllers:    8
+`
+	l, err := parseChipsetLimits(out, ChipsetICH9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.NICs != 36 || l.Controllers[SysBusSATA] != 8 || l.Controllers[SysBusPCIe] != 1 || l.Controllers[SysBusVirtIO] != 1 {
+		t.Errorf("limits = %+v", l)
+	}
+	if err := l.Check(8, map[SystemBus]uint{SysBusSATA: 2}); err != nil {
+		t.Error(err)
+	}
+	l, err = parseChipsetLimits(out, ChipsetPIIX3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(9, nil); err == nil {
+		t.Error("9 NICs fit the PIIX3 chipset")
+	}
+	if err := l.Check(1, map[SystemBus]uint{SysBusSATA: 2}); err == nil {
+		t.Error("2 SATA controllers fit the PIIX3 chipset")
+	}
+	if _, err := parseChipsetLimits(out, Chipset("armv8")); err == nil {
+		t.Error("limits found for unknown chipset")
+	}
+}
+
+func TestDeviceCounts(t *testing.T) {
+	vs, err := parseVMSettings(`nic1="none"
+nic2="none"
+nic3="nat"
+nic4="none"
+storagecontrollertype0="IntelAhci"
+storagecontrollertype1="PIIX4"
+storagecontrollertype2="IntelAhci"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nic, ctls := vs.deviceCounts()
+	if nic != 3 {
+		t.Errorf("highest NIC = %d, want 3", nic)
+	}
+	if want := map[SystemBus]uint{SysBusSATA: 2, SysBusIDE: 1}; !reflect.DeepEqual(ctls, want) {
+		t.Errorf("controllers = %v, want %v", ctls, want)
+	}
+}
+
+func TestSetIOMMUChipset(t *testing.T) {
+	m := &Machine{Chipset: ChipsetPIIX3}
+	if err := m.SetIOMMU(IOMMUAMD); err != ErrIOMMUChipset {
+		t.Errorf("SetIOMMU on PIIX3 = %v, want %v", err, ErrIOMMUChipset)
+	}
+}
+
+func TestChipsetLimitsChecked(t *testing.T) {
+	dir := useFakeVBM(t, `#!/bin/sh
+case "$1" in
+list)
+	echo 'Maximum PIIX3 Network Adapter count: 8'
+	echo 'Maximum PIIX3 SATA Controllers:  1'
+	echo 'Maximum PIIX3 IDE Controllers:   1' ;;
+showvminfo)
+	echo 'name="web"'
+	echo 'UUID="uuid"'
+	echo 'chipset="piix3"'
+	echo 'nic1="nat"'
+	echo 'storagecontrollername0="SATA"'
+	echo 'storagecontrollertype0="IntelAhci"' ;;
+*)
+	echo "$*" >> "$(dirname "$0")/log" ;;
+esac
+`)
+	m := &Machine{UUID: "uuid", Chipset: ChipsetPIIX3, State: Poweroff}
+	if err := m.SetNIC(9, NIC{Network: NICNetNAT}); err == nil {
+		t.Error("NIC 9 fits the PIIX3 chipset")
+	}
+	if err := m.AddStorageCtl("SATA2", StorageController{SysBus: SysBusSATA}); err == nil {
+		t.Error("2 SATA controllers fit the PIIX3 chipset")
+	}
+	if err := m.SetNIC(8, NIC{Network: NICNetNAT}); err != nil {
+		t.Error(err)
+	}
+	if err := m.AddStorageCtl("IDE", StorageController{SysBus: SysBusIDE}); err != nil {
+		t.Error(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(b), "\n"); got != 2 {
+		t.Errorf("ran %d commands, want 2:\n%s", got, b)
+	}
+}
@@ -0,0 +1,67 @@
+// Command vbox-exporter serves Prometheus metrics about the VirtualBox host
+// and its registered machines.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xshellinc/go-virtualbox"
+	"github.com/xshellinc/go-virtualbox/exporter"
+)
+
+func main() {
+	listen := flag.String("listen", ":9105", "address to listen on")
+	path := flag.String("path", "/metrics", "path to serve metrics on")
+	period := flag.Duration("period", 10*time.Second, "sampling period of the VirtualBox metrics")
+	metrics := flag.String("metrics", "", "comma-separated VirtualBox metrics to collect (default all)")
+	verbose := flag.Bool("v", false, "log VBoxManage invocations")
+	flag.Parse()
+
+	virtualbox.Verbose = *verbose
+	h := exporter.NewHandler()
+	if *metrics != "" {
+		h.Metrics = strings.Split(*metrics, ",")
+	}
+
+	// Metrics collection only covers the machines registered at setup time,
+	// so repeat it when machines are added or removed. Setting up again
+	// discards the collected samples, so it is not done otherwise.
+	var (
+		refs  string
+		setUp bool
+	)
+	setup := func() {
+		rs, err := virtualbox.ListMachineRefs()
+		if err != nil {
+			log.Printf("listing machines: %v", err)
+			return
+		}
+		ids := make([]string, len(rs))
+		for i, r := range rs {
+			ids[i] = r.UUID
+		}
+		sort.Strings(ids)
+		if cur := strings.Join(ids, ","); !setUp || cur != refs {
+			if err := virtualbox.SetupMetrics(*period, 1, "", h.Metrics...); err != nil {
+				log.Printf("setting up metrics: %v", err)
+				return
+			}
+			refs, setUp = cur, true
+		}
+	}
+	setup()
+	go func() {
+		for range time.Tick(time.Minute) {
+			setup()
+		}
+	}()
+
+	http.Handle(*path, h)
+	log.Printf("listening on %s", *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
@@ -0,0 +1,206 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Flag names as reported by showvminfo.
+var flagNames = map[string]Flag{
+	"acpi":             F_acpi,
+	"ioapic":           F_ioapic,
+	"rtcuseutc":        F_rtcuseutc,
+	"cpuhotplug":       F_cpuhotplug,
+	"pae":              F_pae,
+	"longmode":         F_longmode,
+	"synthcpu":         F_synthcpu,
+	"hpet":             F_hpet,
+	"hwvirtex":         F_hwvirtex,
+	"triplefaultreset": F_triplefaultreset,
+	"nestedpaging":     F_nestedpaging,
+	"largepages":       F_largepages,
+	"vtxvpid":          F_vtxvpid,
+	"vtxux":            F_vtxux,
+	"accelerate3d":     F_accelerate3d,
+}
+
+// ParavirtProvider represents the paravirtualization interface presented to
+// the guest.
+type ParavirtProvider string
+
+const (
+	ParavirtNone    = ParavirtProvider("none")
+	ParavirtDefault = ParavirtProvider("default")
+	ParavirtLegacy  = ParavirtProvider("legacy")
+	ParavirtMinimal = ParavirtProvider("minimal")
+	ParavirtHyperV  = ParavirtProvider("hyperv")
+	ParavirtKVM     = ParavirtProvider("kvm")
+)
+
+// CPUIDLeaf is an override of the register values the guest sees for a CPUID
+// leaf and subleaf.
+type CPUIDLeaf struct {
+	Leaf    uint32
+	Subleaf uint32
+	EAX     uint32
+	EBX     uint32
+	ECX     uint32
+	EDX     uint32
+}
+
+func (l CPUIDLeaf) id() string {
+	if l.Subleaf != 0 {
+		return fmt.Sprintf("%x:%x", l.Leaf, l.Subleaf)
+	}
+	return fmt.Sprintf("%x", l.Leaf)
+}
+
+// parseCPUIDLeaf parses a cpuid= value of showvminfo: comma-separated hex
+// numbers, with the subleaf missing before VirtualBox 6.1.
+func parseCPUIDLeaf(val string) (CPUIDLeaf, error) {
+	f := strings.Split(val, ",")
+	if len(f) == 5 {
+		f = append(f[:1], append([]string{"0"}, f[1:]...)...)
+	}
+	if len(f) != 6 {
+		return CPUIDLeaf{}, fmt.Errorf("invalid CPUID leaf %q", val)
+	}
+	var n [6]uint32
+	for i, s := range f {
+		v, err := strconv.ParseUint(strings.TrimSpace(s), 16, 32)
+		if err != nil {
+			return CPUIDLeaf{}, fmt.Errorf("invalid CPUID leaf %q: %v", val, err)
+		}
+		n[i] = uint32(v)
+	}
+	return CPUIDLeaf{n[0], n[1], n[2], n[3], n[4], n[5]}, nil
+}
+
+// parseCPU sets the CPU related field described by a showvminfo key. It
+// returns false if the key is not CPU related.
+func (m *Machine) parseCPU(key, val string) (bool, error) {
+	if f, ok := flagNames[key]; ok {
+		if val == "on" {
+			m.Flag |= f
+		} else {
+			m.Flag &^= f
+		}
+		return true, nil
+	}
+	switch key {
+	case "cpuexecutioncap":
+		n, err := strconv.ParseUint(val, 10, 32)
+		if err != nil {
+			return true, err
+		}
+		m.CPUExecutionCap = uint(n)
+	case "cpu-profile":
+		m.CPUProfile = val
+	case "nested-hw-virt":
+		m.NestedHWVirt = (val == "on")
+	case "paravirtprovider":
+		m.ParavirtProvider = ParavirtProvider(val)
+	case "cpuid":
+		l, err := parseCPUIDLeaf(val)
+		if err != nil {
+			return true, err
+		}
+		m.CPUIDLeaves = append(m.CPUIDLeaves, l)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// SetCPUExecutionCap limits the host CPU time each virtual CPU may use, in
+// percent (1--100). It also works on running machines.
+func (m *Machine) SetCPUExecutionCap(percent uint) error {
+	var err error
+	if m.online() {
+		err = vbm("controlvm", m.id(), "cpuexecutioncap", fmt.Sprintf("%d", percent))
+	} else {
+		err = vbm("modifyvm", m.id(), "--cpuexecutioncap", fmt.Sprintf("%d", percent))
+	}
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetCPUProfile selects the CPU profile presented to the guest, e.g. "host"
+// or "Intel Core i7-6700K".
+func (m *Machine) SetCPUProfile(profile string) error {
+	if err := vbm("modifyvm", m.id(), "--cpu-profile", profile); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetNestedHWVirt enables or disables nested hardware virtualization.
+func (m *Machine) SetNestedHWVirt(on bool) error {
+	if err := vbm("modifyvm", m.id(), "--nested-hw-virt", bool2string(on)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetParavirtProvider selects the paravirtualization interface.
+func (m *Machine) SetParavirtProvider(p ParavirtProvider) error {
+	if err := vbm("modifyvm", m.id(), "--paravirtprovider", string(p)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetCPUID overrides the register values of a CPUID leaf.
+func (m *Machine) SetCPUID(l CPUIDLeaf) error {
+	err := vbm("modifyvm", m.id(), "--cpuidset", l.id(),
+		fmt.Sprintf("%08x", l.EAX), fmt.Sprintf("%08x", l.EBX),
+		fmt.Sprintf("%08x", l.ECX), fmt.Sprintf("%08x", l.EDX))
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// RemoveCPUID removes the override of a CPUID leaf and subleaf.
+func (m *Machine) RemoveCPUID(leaf, subleaf uint32) error {
+	if err := vbm("modifyvm", m.id(), "--cpuidremove", CPUIDLeaf{Leaf: leaf, Subleaf: subleaf}.id()); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// RemoveAllCPUID removes all CPUID overrides.
+func (m *Machine) RemoveAllCPUID() error {
+	if err := vbm("modifyvm", m.id(), "--cpuidremoveall"); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// PlugCPU adds the virtual CPU with the given index. CPU hot-plug (F_cpuhotplug)
+// must be enabled; running machines are changed live.
+func (m *Machine) PlugCPU(n uint) error {
+	return m.hotplugCPU("plugcpu", n)
+}
+
+// UnplugCPU removes the virtual CPU with the given index. CPU 0 cannot be
+// removed. Running machines are changed live, which needs guest support.
+func (m *Machine) UnplugCPU(n uint) error {
+	return m.hotplugCPU("unplugcpu", n)
+}
+
+func (m *Machine) hotplugCPU(op string, n uint) error {
+	var err error
+	if m.online() {
+		err = vbm("controlvm", m.id(), op, fmt.Sprintf("%d", n))
+	} else {
+		err = vbm("modifyvm", m.id(), "--"+op, fmt.Sprintf("%d", n))
+	}
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
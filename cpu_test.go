@@ -0,0 +1,34 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestParseCPU(t *testing.T) {
+	m := &Machine{Flag: F_acpi}
+	for _, kv := range [][2]string{
+		{"acpi", "off"},
+		{"pae", "on"},
+		{"cpuexecutioncap", "50"},
+		{"nested-hw-virt", "on"},
+		{"cpuid", "00000001,00000000,000306a9,00020800,80000201,178bfbff"},
+		{"cpuid", "80000001,00000000,00000000,00000021,28100800"},
+	} {
+		if ok, err := m.parseCPU(kv[0], kv[1]); !ok || err != nil {
+			t.Fatalf("parseCPU(%q, %q) = %v, %v", kv[0], kv[1], ok, err)
+		}
+	}
+	if m.Flag != F_pae || m.CPUExecutionCap != 50 || !m.NestedHWVirt {
+		t.Errorf("machine = %+v", m)
+	}
+	want := []CPUIDLeaf{
+		{Leaf: 1, EAX: 0x306a9, EBX: 0x20800, ECX: 0x80000201, EDX: 0x178bfbff},
+		{Leaf: 0x80000001, EBX: 0, ECX: 0x21, EDX: 0x28100800},
+	}
+	if len(m.CPUIDLeaves) != 2 || m.CPUIDLeaves[0] != want[0] || m.CPUIDLeaves[1] != want[1] {
+		t.Errorf("CPUID leaves = %+x, want %+x", m.CPUIDLeaves, want)
+	}
+	if ok, _ := m.parseCPU("memory", "1024"); ok {
+		t.Error("memory parsed as CPU setting")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
 // MakeDiskImage makes a disk image at dest with the given size in MB. If r is
@@ -68,3 +70,38 @@ func ZeroFill(w io.Writer, n int64) error {
 	}
 	return nil
 }
+
+// CreateHardDisk creates a dynamically allocated disk image at filename with
+// the given size in MB. The format is derived from the file extension, e.g.
+// .vdi or .vmdk.
+func CreateHardDisk(filename string, size uint) error {
+	args := []string{"createmedium", "disk", "--filename", filename, "--size", fmt.Sprintf("%d", size)}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".vmdk":
+		args = append(args, "--format", "VMDK")
+	case ".vhd":
+		args = append(args, "--format", "VHD")
+	}
+	return vbm(args...)
+}
+
+// CloseMedium removes a disk, DVD or floppy image from the media registry and,
+// if del is set, deletes the image file.
+func CloseMedium(kind DriveType, location string, del bool) error {
+	var typ string
+	switch kind {
+	case DriveHDD:
+		typ = "disk"
+	case DriveDVD:
+		typ = "dvd"
+	case DriveFDD:
+		typ = "floppy"
+	default:
+		return fmt.Errorf("unknown drive type %q", kind)
+	}
+	args := []string{"closemedium", typ, location}
+	if del {
+		args = append(args, "--delete")
+	}
+	return vbm(args...)
+}
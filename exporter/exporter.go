@@ -0,0 +1,206 @@
+/*
+Package exporter exposes the state of VirtualBox machines and the samples of
+the VirtualBox metrics subsystem in the Prometheus text exposition format.
+
+Machine metrics are labelled by machine name, UUID and comma-separated groups.
+Metrics subsystem samples are exported as gauges named after the VirtualBox
+metric, e.g. CPU/Load/User of the host becomes
+virtualbox_host_cpu_load_user_percent and Guest/RAM/Usage/Free:avg of a machine
+becomes virtualbox_vm_guest_ram_usage_free_avg_bytes. Only metrics set up with
+virtualbox.SetupMetrics are reported.
+*/
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/xshellinc/go-virtualbox"
+)
+
+// states are always exported, so that alerts can match them being 0. Any
+// other state of a machine is exported as well.
+var states = []virtualbox.MachineState{
+	virtualbox.Poweroff,
+	virtualbox.Running,
+	virtualbox.Paused,
+	virtualbox.Saved,
+	virtualbox.Aborted,
+}
+
+// Handler serves the metrics of all registered machines and the host.
+type Handler struct {
+	// Metrics restricts the exported metrics subsystem samples to the given
+	// VirtualBox metric names, e.g. "CPU/Load/User". Nil exports all.
+	Metrics []string
+}
+
+// NewHandler returns a Handler exporting all metrics.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ms, err := virtualbox.ListMachines()
+	if err != nil {
+		log.Printf("exporter: listing machines: %v", err)
+		// Still export the machines that could be listed.
+		if _, ok := err.(virtualbox.MachineErrors); !ok {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	series, err := virtualbox.QueryMetrics("", h.Metrics...)
+	if err != nil {
+		log.Printf("exporter: querying metrics: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, ms, series); err != nil {
+		log.Printf("exporter: writing metrics: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+type family struct {
+	help    string
+	samples []sample
+}
+
+// Write writes the given machines and metric samples in the Prometheus text
+// exposition format.
+func Write(w io.Writer, ms []*virtualbox.Machine, series []virtualbox.MetricSeries) error {
+	fams := map[string]*family{}
+	add := func(name, help, labels string, v float64) {
+		f := fams[name]
+		if f == nil {
+			f = &family{help: help}
+			fams[name] = f
+		}
+		f.samples = append(f.samples, sample{labels, v})
+	}
+
+	byName := map[string]*virtualbox.Machine{}
+	for _, m := range ms {
+		byName[m.Name] = m
+		l := machineLabels(m)
+		accessible := 1.0
+		if m.Inaccessible {
+			accessible = 0
+		}
+		add("virtualbox_vm_accessible", "Whether the machine settings can be loaded.", l, accessible)
+		if m.Inaccessible {
+			continue
+		}
+		known := false
+		for _, s := range states {
+			v := 0.0
+			if m.State == s {
+				v, known = 1, true
+			}
+			add("virtualbox_vm_state", "Current state of the machine.", l+`,state="`+escape(string(s))+`"`, v)
+		}
+		if !known && m.State != "" {
+			add("virtualbox_vm_state", "Current state of the machine.", l+`,state="`+escape(string(m.State))+`"`, 1)
+		}
+		add("virtualbox_vm_cpus", "Number of configured virtual CPUs.", l, float64(m.CPUs))
+		add("virtualbox_vm_memory_bytes", "Configured main memory in bytes.", l, float64(m.Memory)*1024*1024)
+		add("virtualbox_vm_vram_bytes", "Configured video memory in bytes.", l, float64(m.VRAM)*1024*1024)
+	}
+
+	for _, s := range series {
+		if len(s.Values) == 0 {
+			continue
+		}
+		name, scale := metricName(s.Metric, s.Unit)
+		help := fmt.Sprintf("VirtualBox metric %s (%s).", s.Metric, s.Unit)
+		if s.Object == "host" {
+			add("virtualbox_host_"+name, help, "", s.Last()*scale)
+			continue
+		}
+		m := byName[s.Object]
+		if m == nil {
+			m = &virtualbox.Machine{Name: s.Object}
+		}
+		add("virtualbox_vm_"+name, help, machineLabels(m), s.Last()*scale)
+	}
+
+	names := make([]string, 0, len(fams))
+	for name := range fams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := fams[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, f.help, name); err != nil {
+			return err
+		}
+		for _, s := range f.samples {
+			labels := ""
+			if s.labels != "" {
+				labels = "{" + s.labels + "}"
+			}
+			if _, err := fmt.Fprintf(w, "%s%s %g\n", name, labels, s.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func machineLabels(m *virtualbox.Machine) string {
+	return fmt.Sprintf(`name="%s",uuid="%s",groups="%s"`,
+		escape(m.Name), escape(m.UUID), escape(strings.Join(m.Groups, ",")))
+}
+
+// metricName converts a VirtualBox metric name and unit into a Prometheus
+// metric name suffix and the factor converting values to base units.
+func metricName(metric, unit string) (string, float64) {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, metric)
+
+	switch unit {
+	case "%":
+		return name + "_percent", 1
+	case "B":
+		return name + "_bytes", 1
+	case "kB":
+		return name + "_bytes", 1024
+	case "B/s":
+		return name + "_bytes_per_second", 1
+	case "MHz":
+		return name + "_hertz", 1e6
+	case "ms":
+		return name + "_seconds", 1e-3
+	}
+	return name, 1
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,39 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xshellinc/go-virtualbox"
+)
+
+func TestWrite(t *testing.T) {
+	ms := []*virtualbox.Machine{
+		{Name: `web "1"`, UUID: "4e5f", Groups: []string{"/ci", "/web"}, State: virtualbox.Aborted, CPUs: 2, Memory: 512},
+		{Name: "db", UUID: "6a7b", State: virtualbox.MachineState("gurumeditation")},
+	}
+	series := []virtualbox.MetricSeries{
+		{Object: "host", Metric: "CPU/Load/User", Unit: "%", Values: []float64{1, 2.5}},
+		{Object: `web "1"`, Metric: "Guest/RAM/Usage/Free:avg", Unit: "kB", Values: []float64{4}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, ms, series); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE virtualbox_host_cpu_load_user_percent gauge\nvirtualbox_host_cpu_load_user_percent 2.5\n",
+		`virtualbox_vm_guest_ram_usage_free_avg_bytes{name="web \"1\"",uuid="4e5f",groups="/ci,/web"} 4096` + "\n",
+		`virtualbox_vm_state{name="web \"1\"",uuid="4e5f",groups="/ci,/web",state="aborted"} 1` + "\n",
+		`virtualbox_vm_state{name="web \"1\"",uuid="4e5f",groups="/ci,/web",state="running"} 0` + "\n",
+		`virtualbox_vm_state{name="db",uuid="6a7b",groups="",state="gurumeditation"} 1` + "\n",
+		`virtualbox_vm_state{name="db",uuid="6a7b",groups="",state="running"} 0` + "\n",
+		`virtualbox_vm_memory_bytes{name="web \"1\"",uuid="4e5f",groups="/ci,/web"} 5.36870912e+08` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package virtualbox
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Firmware represents the firmware type of a machine.
+type Firmware string
+
+const (
+	FirmwareBIOS  = Firmware("bios")
+	FirmwareEFI   = Firmware("efi")
+	FirmwareEFI32 = Firmware("efi32")
+	FirmwareEFI64 = Firmware("efi64")
+)
+
+// TPMType represents the type of the trusted platform module of a machine.
+type TPMType string
+
+const (
+	TPMNone     = TPMType("none")
+	TPM12       = TPMType("1.2")
+	TPM20       = TPMType("2.0")
+	TPMHost     = TPMType("host")
+	TPMSoftware = TPMType("swtpm")
+)
+
+// parseTPMType converts the TPM type reported by showvminfo (e.g. "v2_0") to
+// the value accepted by modifyvm.
+func parseTPMType(val string) TPMType {
+	switch val {
+	case "v1_2":
+		return TPM12
+	case "v2_0":
+		return TPM20
+	}
+	return TPMType(val)
+}
+
+// SetFirmware selects the firmware of the machine.
+func (m *Machine) SetFirmware(fw Firmware) error {
+	if err := vbm("modifyvm", m.id(), "--firmware", string(fw)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetTPM selects the trusted platform module type. Requires VirtualBox 7.0.
+func (m *Machine) SetTPM(t TPMType) error {
+	if err := vbm("modifyvm", m.id(), "--tpm-type", string(t)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// EFI variable store management through modifynvram requires VirtualBox 7.0
+// and a machine using EFI firmware.
+
+// InitNVRAM initializes the UEFI variable store of the machine.
+func (m *Machine) InitNVRAM() error {
+	return vbm("modifynvram", m.id(), "inituefivarstore")
+}
+
+// EnrollMSKeys enrolls the default Microsoft KEK and DB signatures.
+func (m *Machine) EnrollMSKeys() error {
+	return vbm("modifynvram", m.id(), "enrollmssignatures")
+}
+
+// EnrollOraclePK enrolls the Oracle platform key.
+func (m *Machine) EnrollOraclePK() error {
+	return vbm("modifynvram", m.id(), "enrollorclpk")
+}
+
+// EnrollPK enrolls the platform key stored in file, owned by the given owner
+// UUID.
+func (m *Machine) EnrollPK(file, owner string) error {
+	return vbm("modifynvram", m.id(), "enrollpk", "--platform-key="+file, "--owner-uuid="+owner)
+}
+
+// EnrollMOK enrolls the machine owner key stored in file, owned by the given
+// owner UUID.
+func (m *Machine) EnrollMOK(file, owner string) error {
+	return vbm("modifynvram", m.id(), "enrollmok", "--mok="+file, "--owner-uuid="+owner)
+}
+
+// SetSecureBoot enables or disables UEFI secure boot. The platform key must be
+// enrolled first.
+func (m *Machine) SetSecureBoot(on bool) error {
+	opt := "--disable"
+	if on {
+		opt = "--enable"
+	}
+	return vbm("modifynvram", m.id(), "secureboot", opt)
+}
+
+// NVRAMVar identifies a UEFI variable.
+type NVRAMVar struct {
+	Name  string
+	Owner string // vendor GUID
+}
+
+// NVRAMVars lists the variables in the UEFI variable store of the machine.
+func (m *Machine) NVRAMVars() ([]NVRAMVar, error) {
+	out, err := vbmOut("modifynvram", m.id(), "listvars")
+	if err != nil {
+		return nil, err
+	}
+	return parseNVRAMVars(out), nil
+}
+
+func parseNVRAMVars(out string) []NVRAMVar {
+	vars := []NVRAMVar{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) < 2 {
+			continue
+		}
+		vars = append(vars, NVRAMVar{
+			Name:  strings.Join(f[:len(f)-1], " "),
+			Owner: f[len(f)-1],
+		})
+	}
+	return vars
+}
+
+// QueryNVRAMVar returns the data of a UEFI variable.
+func (m *Machine) QueryNVRAMVar(v NVRAMVar) ([]byte, error) {
+	f, err := os.CreateTemp("", "vbox-nvram-*")
+	if err != nil {
+		return nil, err
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	args := []string{"modifynvram", m.id(), "queryvar", "--name=" + v.Name, "--filename=" + name}
+	if v.Owner != "" {
+		args = append(args, "--owner-uuid="+v.Owner)
+	}
+	if err := vbm(args...); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,16 @@
+package virtualbox
+
+import (
+	"testing"
+)
+
+func TestParseNVRAMVars(t *testing.T) {
+	out := `PK                               8be4df61-93ca-11d2-aa0d-00e098032b8c
+Boot Order Backup                5b0ea7f4-5db1-4a3f-a1d3-3b5d1e3e1c3a
+`
+	vars := parseNVRAMVars(out)
+	if len(vars) != 2 || vars[0].Name != "PK" || vars[1].Name != "Boot Order Backup" ||
+		vars[1].Owner != "5b0ea7f4-5db1-4a3f-a1d3-3b5d1e3e1c3a" {
+		t.Errorf("vars = %+v", vars)
+	}
+}
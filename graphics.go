@@ -0,0 +1,96 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GraphicsController represents the virtual graphics adapter of a machine.
+type GraphicsController string
+
+const (
+	GraphicsNone     = GraphicsController("none")
+	GraphicsVBoxVGA  = GraphicsController("vboxvga")
+	GraphicsVMSVGA   = GraphicsController("vmsvga")
+	GraphicsVBoxSVGA = GraphicsController("vboxsvga")
+)
+
+// Graphics represents the display settings of a machine. The amount of video
+// memory is Machine.VRAM.
+type Graphics struct {
+	Controller   GraphicsController
+	Monitors     uint
+	Accelerate2D bool
+	Accelerate3D bool // same as the F_accelerate3d flag
+}
+
+// parse sets the Graphics field described by a showvminfo key. It returns
+// false if the key is not graphics related.
+func (g *Graphics) parse(key, val string) bool {
+	switch key {
+	case "graphicscontroller":
+		g.Controller = GraphicsController(val)
+	case "monitorcount":
+		if n, err := strconv.ParseUint(val, 10, 32); err == nil {
+			g.Monitors = uint(n)
+		}
+	case "accelerate2dvideo":
+		g.Accelerate2D = (val == "on")
+	default:
+		return false
+	}
+	return true
+}
+
+// SetGraphics changes the display settings of the machine, which must not be
+// running.
+func (m *Machine) SetGraphics(g Graphics) error {
+	args := []string{"modifyvm", m.id(),
+		"--accelerate2dvideo", bool2string(g.Accelerate2D),
+		"--accelerate3d", bool2string(g.Accelerate3D),
+	}
+	if g.Controller != "" {
+		args = append(args, "--graphicscontroller", string(g.Controller))
+	}
+	if g.Monitors > 0 {
+		args = append(args, "--monitorcount", fmt.Sprintf("%d", g.Monitors))
+	}
+	if err := vbm(args...); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetVideoModeHint asks the guest of the running machine to switch the given
+// display to a resolution and color depth. Requires the Guest Additions.
+func (m *Machine) SetVideoModeHint(display, width, height, bpp uint) error {
+	return vbm("controlvm", m.id(), "setvideomodehint",
+		fmt.Sprintf("%d", width), fmt.Sprintf("%d", height), fmt.Sprintf("%d", bpp),
+		fmt.Sprintf("%d", display))
+}
+
+// ScreenLayout describes the position and mode of a guest display.
+type ScreenLayout struct {
+	Enabled bool
+	Primary bool
+	X, Y    int // origin in the virtual desktop
+	Width   uint
+	Height  uint
+	BPP     uint
+}
+
+// SetScreenLayout changes the layout of a display of the running machine.
+// Requires the Guest Additions.
+func (m *Machine) SetScreenLayout(display uint, l ScreenLayout) error {
+	args := []string{"controlvm", m.id(), "setscreenlayout", fmt.Sprintf("%d", display)}
+	if !l.Enabled {
+		return vbm(append(args, "off")...)
+	}
+	state := "on"
+	if l.Primary {
+		state = "primary"
+	}
+	return vbm(append(args, state,
+		fmt.Sprintf("%d", l.X), fmt.Sprintf("%d", l.Y),
+		fmt.Sprintf("%d", l.Width), fmt.Sprintf("%d", l.Height), fmt.Sprintf("%d", l.BPP))...)
+}
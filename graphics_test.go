@@ -0,0 +1,35 @@
+package virtualbox
+
+import "testing"
+
+func TestGraphicsParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		out  string
+		want Graphics
+	}{
+		{"6.1", `vram=16
+graphicscontroller="vmsvga"
+monitorcount=2
+accelerate3d="on"
+accelerate2dvideo="off"
+`, Graphics{Controller: GraphicsVMSVGA, Monitors: 2}},
+		{"5.2", `graphicscontroller="vboxvga"
+monitorcount=1
+accelerate2dvideo="on"
+`, Graphics{Controller: GraphicsVBoxVGA, Monitors: 1, Accelerate2D: true}},
+	} {
+		var g Graphics
+		scanVMInfo(tc.out, func(key, val string) {
+			g.parse(key, val)
+		})
+		if g != tc.want {
+			t.Errorf("%s: Graphics = %+v, want %+v", tc.name, g, tc.want)
+		}
+	}
+	// 3D acceleration is a machine flag and taken from there.
+	var g Graphics
+	if g.parse("accelerate3d", "on") {
+		t.Error("parse accepted accelerate3d")
+	}
+}
@@ -0,0 +1,129 @@
+package virtualbox
+
+import (
+	"bufio"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RootGroup is the group of machines not assigned to any other group.
+const RootGroup = "/"
+
+// parseGroups splits the comma-separated group list of showvminfo.
+func parseGroups(val string) []string {
+	if val == "" {
+		return []string{RootGroup}
+	}
+	return strings.Split(val, ",")
+}
+
+// groups returns the groups of the machine, defaulting to RootGroup.
+func (m *Machine) groups() []string {
+	if len(m.Groups) == 0 {
+		return []string{RootGroup}
+	}
+	return m.Groups
+}
+
+// InGroup reports whether the machine belongs to group or one of its
+// subgroups.
+func (m *Machine) InGroup(group string) bool {
+	group = path.Clean("/" + group)
+	for _, g := range m.groups() {
+		if g == group || group == RootGroup || strings.HasPrefix(g, group+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGroups assigns the machine to the given groups, e.g. "/project/web". A
+// machine without groups belongs to RootGroup.
+func (m *Machine) SetGroups(groups ...string) error {
+	if err := vbm("modifyvm", m.id(), "--groups", strings.Join(groups, ",")); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// ListGroups lists all machine groups in use.
+func ListGroups() ([]string, error) {
+	out, err := vbmOut("list", "groups")
+	if err != nil {
+		return nil, err
+	}
+	groups := []string{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		if g := strings.Trim(s.Text(), `" `); g != "" {
+			groups = append(groups, g)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ListMachinesInGroup lists the machines belonging to group or one of its
+// subgroups. Errors are reported as in ListMachines.
+func ListMachinesInGroup(group string) ([]*Machine, error) {
+	ms, err := ListMachines()
+	if _, ok := err.(MachineErrors); err != nil && !ok {
+		return nil, err
+	}
+	res := []*Machine{}
+	for _, m := range ms {
+		if m.InGroup(group) {
+			res = append(res, m)
+		}
+	}
+	return res, err
+}
+
+// GroupTree is a machine group with its machines and subgroups.
+type GroupTree struct {
+	Name     string // full path of the group, e.g. "/project/web"
+	Machines []*Machine
+	Groups   []*GroupTree
+}
+
+// MachineGroupTree returns the tree of all machine groups rooted at RootGroup.
+// A machine in several groups appears under each of them. Errors are reported
+// as in ListMachines.
+func MachineGroupTree() (*GroupTree, error) {
+	ms, err := ListMachines()
+	if _, ok := err.(MachineErrors); err != nil && !ok {
+		return nil, err
+	}
+	return NewGroupTree(ms), err
+}
+
+// NewGroupTree arranges the given machines into a group tree rooted at
+// RootGroup. Subgroups are sorted by name.
+func NewGroupTree(ms []*Machine) *GroupTree {
+	root := &GroupTree{Name: RootGroup}
+	nodes := map[string]*GroupTree{RootGroup: root}
+	var node func(name string) *GroupTree
+	node = func(name string) *GroupTree {
+		if t, ok := nodes[name]; ok {
+			return t
+		}
+		t := &GroupTree{Name: name}
+		nodes[name] = t
+		parent := node(path.Dir(name))
+		parent.Groups = append(parent.Groups, t)
+		return t
+	}
+	for _, m := range ms {
+		for _, g := range m.groups() {
+			t := node(path.Clean("/" + g))
+			t.Machines = append(t.Machines, m)
+		}
+	}
+	for _, t := range nodes {
+		sort.Slice(t.Groups, func(i, j int) bool { return t.Groups[i].Name < t.Groups[j].Name })
+	}
+	return root
+}
@@ -0,0 +1,28 @@
+package virtualbox
+
+import "testing"
+
+func TestNewGroupTree(t *testing.T) {
+	a := &Machine{Name: "a", Groups: []string{"/proj/web", "/ci"}}
+	b := &Machine{Name: "b", Groups: parseGroups("")}
+	c := &Machine{Name: "c", Groups: []string{"/proj"}}
+
+	root := NewGroupTree([]*Machine{a, b, c})
+	if len(root.Machines) != 1 || root.Machines[0] != b {
+		t.Errorf("root machines = %v, want [b]", root.Machines)
+	}
+	if len(root.Groups) != 2 || root.Groups[0].Name != "/ci" || root.Groups[1].Name != "/proj" {
+		t.Fatalf("root groups = %+v", root.Groups)
+	}
+	proj := root.Groups[1]
+	if len(proj.Machines) != 1 || proj.Machines[0] != c {
+		t.Errorf("/proj machines = %v, want [c]", proj.Machines)
+	}
+	if len(proj.Groups) != 1 || proj.Groups[0].Name != "/proj/web" || proj.Groups[0].Machines[0] != a {
+		t.Errorf("/proj groups = %+v", proj.Groups)
+	}
+
+	if !a.InGroup("/proj") || !a.InGroup("ci") || a.InGroup("/pro") || !b.InGroup("/") || b.InGroup("/proj") {
+		t.Error("InGroup mismatch")
+	}
+}
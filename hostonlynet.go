@@ -44,6 +44,11 @@ func CreateHostonlyNet() (*HostonlyNet, error) {
 	return &HostonlyNet{Name: res[1]}, nil
 }
 
+// Remove removes the host-only network interface.
+func (n *HostonlyNet) Remove() error {
+	return vbm("hostonlyif", "remove", n.Name)
+}
+
 // Config changes the configuration of the host-only network.
 func (n *HostonlyNet) Config() error {
 	if n.IPv4.IP != nil && n.IPv4.Mask != nil {
@@ -0,0 +1,286 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Key represents a key on a PS/2 keyboard by its scan code set 1 make code.
+// Extended keys carry the 0xe0 prefix in the high byte (e.g. 0xe048 for the
+// up arrow).
+type Key struct {
+	Code  uint16
+	Shift bool // whether shift must be held to produce the character
+}
+
+// Press returns the scancodes sent when the key is pressed.
+func (k Key) Press() []byte {
+	if k.Code > 0xff {
+		return []byte{byte(k.Code >> 8), byte(k.Code)}
+	}
+	return []byte{byte(k.Code)}
+}
+
+// Release returns the scancodes sent when the key is released.
+func (k Key) Release() []byte {
+	if k.Code > 0xff {
+		return []byte{byte(k.Code >> 8), byte(k.Code) | 0x80}
+	}
+	return []byte{byte(k.Code) | 0x80}
+}
+
+// Keymap maps characters to the keys producing them on a keyboard layout.
+type Keymap map[rune]Key
+
+// DefaultKeymap is the layout used by TypeString.
+var DefaultKeymap = KeymapUS
+
+// KeymapUS is the US (QWERTY) keyboard layout.
+var KeymapUS = Keymap{
+	'1': {0x02, false}, '!': {0x02, true},
+	'2': {0x03, false}, '@': {0x03, true},
+	'3': {0x04, false}, '#': {0x04, true},
+	'4': {0x05, false}, '$': {0x05, true},
+	'5': {0x06, false}, '%': {0x06, true},
+	'6': {0x07, false}, '^': {0x07, true},
+	'7': {0x08, false}, '&': {0x08, true},
+	'8': {0x09, false}, '*': {0x09, true},
+	'9': {0x0a, false}, '(': {0x0a, true},
+	'0': {0x0b, false}, ')': {0x0b, true},
+	'-': {0x0c, false}, '_': {0x0c, true},
+	'=': {0x0d, false}, '+': {0x0d, true},
+	'q': {0x10, false}, 'Q': {0x10, true},
+	'w': {0x11, false}, 'W': {0x11, true},
+	'e': {0x12, false}, 'E': {0x12, true},
+	'r': {0x13, false}, 'R': {0x13, true},
+	't': {0x14, false}, 'T': {0x14, true},
+	'y': {0x15, false}, 'Y': {0x15, true},
+	'u': {0x16, false}, 'U': {0x16, true},
+	'i': {0x17, false}, 'I': {0x17, true},
+	'o': {0x18, false}, 'O': {0x18, true},
+	'p': {0x19, false}, 'P': {0x19, true},
+	'[': {0x1a, false}, '{': {0x1a, true},
+	']': {0x1b, false}, '}': {0x1b, true},
+	'a': {0x1e, false}, 'A': {0x1e, true},
+	's': {0x1f, false}, 'S': {0x1f, true},
+	'd': {0x20, false}, 'D': {0x20, true},
+	'f': {0x21, false}, 'F': {0x21, true},
+	'g': {0x22, false}, 'G': {0x22, true},
+	'h': {0x23, false}, 'H': {0x23, true},
+	'j': {0x24, false}, 'J': {0x24, true},
+	'k': {0x25, false}, 'K': {0x25, true},
+	'l': {0x26, false}, 'L': {0x26, true},
+	';': {0x27, false}, ':': {0x27, true},
+	'\'': {0x28, false}, '"': {0x28, true},
+	'`': {0x29, false}, '~': {0x29, true},
+	'\\': {0x2b, false}, '|': {0x2b, true},
+	'z': {0x2c, false}, 'Z': {0x2c, true},
+	'x': {0x2d, false}, 'X': {0x2d, true},
+	'c': {0x2e, false}, 'C': {0x2e, true},
+	'v': {0x2f, false}, 'V': {0x2f, true},
+	'b': {0x30, false}, 'B': {0x30, true},
+	'n': {0x31, false}, 'N': {0x31, true},
+	'm': {0x32, false}, 'M': {0x32, true},
+	',': {0x33, false}, '<': {0x33, true},
+	'.': {0x34, false}, '>': {0x34, true},
+	'/': {0x35, false}, '?': {0x35, true},
+	' ': {0x39, false},
+
+	'\t': {0x0f, false},
+	'\n': {0x1c, false},
+}
+
+var keyShift = Key{Code: 0x2a}
+
+// Named keys usable as <name> in TypeString, independent of the layout. Names
+// are matched case-insensitively.
+var namedKeys = map[string]Key{
+	"esc":        {Code: 0x01},
+	"bs":         {Code: 0x0e},
+	"tab":        {Code: 0x0f},
+	"enter":      {Code: 0x1c},
+	"return":     {Code: 0x1c},
+	"leftctrl":   {Code: 0x1d},
+	"leftshift":  {Code: 0x2a},
+	"rightshift": {Code: 0x36},
+	"leftalt":    {Code: 0x38},
+	"spacebar":   {Code: 0x39},
+	"f1":         {Code: 0x3b},
+	"f2":         {Code: 0x3c},
+	"f3":         {Code: 0x3d},
+	"f4":         {Code: 0x3e},
+	"f5":         {Code: 0x3f},
+	"f6":         {Code: 0x40},
+	"f7":         {Code: 0x41},
+	"f8":         {Code: 0x42},
+	"f9":         {Code: 0x43},
+	"f10":        {Code: 0x44},
+	"f11":        {Code: 0x57},
+	"f12":        {Code: 0x58},
+	"rightctrl":  {Code: 0xe01d},
+	"rightalt":   {Code: 0xe038},
+	"home":       {Code: 0xe047},
+	"up":         {Code: 0xe048},
+	"pageup":     {Code: 0xe049},
+	"left":       {Code: 0xe04b},
+	"right":      {Code: 0xe04d},
+	"end":        {Code: 0xe04f},
+	"down":       {Code: 0xe050},
+	"pagedown":   {Code: 0xe051},
+	"insert":     {Code: 0xe052},
+	"del":        {Code: 0xe053},
+	"leftsuper":  {Code: 0xe05b},
+	"rightsuper": {Code: 0xe05c},
+	"menu":       {Code: 0xe05d},
+}
+
+// VBoxManage accepts any number of scancodes per call, but very long command
+// lines overflow the guest keyboard buffer. Send them in chunks.
+const scancodeChunk = 32
+
+// PutScancodes sends raw PS/2 scancodes (set 1) to the keyboard of the running
+// machine.
+func (m *Machine) PutScancodes(codes ...byte) error {
+	for len(codes) > 0 {
+		n := len(codes)
+		if n > scancodeChunk {
+			n = scancodeChunk
+		}
+		args := []string{"controlvm", m.id(), "keyboardputscancode"}
+		for _, c := range codes[:n] {
+			args = append(args, fmt.Sprintf("%02x", c))
+		}
+		if err := vbm(args...); err != nil {
+			return err
+		}
+		codes = codes[n:]
+	}
+	return nil
+}
+
+// TypeString types s on the keyboard of the running machine using
+// DefaultKeymap. Besides plain characters, s may contain the following
+// commands (case-insensitive):
+//
+//	<enter> <return> <esc> <bs> <del> <tab> <spacebar> <insert>
+//	<home> <end> <pageUp> <pageDown> <up> <down> <left> <right> <menu>
+//	<f1> ... <f12>
+//	<leftAlt> <rightAlt> <leftCtrl> <rightCtrl> <leftShift> <rightShift>
+//	<leftSuper> <rightSuper>
+//	<leftAltOn> <leftAltOff> ... to hold and release a modifier key
+//	<wait> <wait5> <wait10> <wait1m30s> to pause for 1s, N seconds or a duration
+//
+// A '<' that does not start a known command is typed literally.
+func (m *Machine) TypeString(s string) error {
+	return m.TypeStringKeymap(s, DefaultKeymap)
+}
+
+// TypeStringKeymap is like TypeString but uses the given keyboard layout.
+func (m *Machine) TypeStringKeymap(s string, km Keymap) error {
+	steps, err := ParseKeySequence(s, km)
+	if err != nil {
+		return err
+	}
+	for _, st := range steps {
+		if err := m.PutScancodes(st.Scancodes...); err != nil {
+			return err
+		}
+		time.Sleep(st.Wait)
+	}
+	return nil
+}
+
+// KeyStep is a run of scancodes to send followed by a pause.
+type KeyStep struct {
+	Scancodes []byte
+	Wait      time.Duration
+}
+
+// ParseKeySequence translates a TypeString command string into the scancodes
+// to send, split at the pauses requested by <wait> commands.
+func ParseKeySequence(s string, km Keymap) ([]KeyStep, error) {
+	steps := []KeyStep{}
+	var codes []byte
+	for len(s) > 0 {
+		if s[0] == '<' {
+			if end := strings.IndexByte(s, '>'); end > 0 {
+				name := strings.ToLower(s[1:end])
+				if d, ok, err := parseWait(name); ok {
+					if err != nil {
+						return nil, err
+					}
+					steps = append(steps, KeyStep{Scancodes: codes, Wait: d})
+					codes = nil
+					s = s[end+1:]
+					continue
+				}
+				if b, ok := namedKeyCodes(name); ok {
+					codes = append(codes, b...)
+					s = s[end+1:]
+					continue
+				}
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s)
+		k, ok := km[r]
+		if !ok {
+			return nil, fmt.Errorf("no key for character %q in keymap", r)
+		}
+		if k.Shift {
+			codes = append(codes, keyShift.Press()...)
+		}
+		codes = append(codes, k.Press()...)
+		codes = append(codes, k.Release()...)
+		if k.Shift {
+			codes = append(codes, keyShift.Release()...)
+		}
+		s = s[size:]
+	}
+	if len(codes) > 0 {
+		steps = append(steps, KeyStep{Scancodes: codes})
+	}
+	return steps, nil
+}
+
+// namedKeyCodes returns the scancodes for a named key command, with the
+// optional "on"/"off" suffix to only press or release it.
+func namedKeyCodes(name string) ([]byte, bool) {
+	if k, ok := namedKeys[name]; ok {
+		return append(k.Press(), k.Release()...), true
+	}
+	if strings.HasSuffix(name, "on") {
+		if k, ok := namedKeys[strings.TrimSuffix(name, "on")]; ok {
+			return k.Press(), true
+		}
+	}
+	if strings.HasSuffix(name, "off") {
+		if k, ok := namedKeys[strings.TrimSuffix(name, "off")]; ok {
+			return k.Release(), true
+		}
+	}
+	return nil, false
+}
+
+// parseWait parses <wait>, <waitN> (seconds) and <waitDURATION> commands. ok
+// reports whether name is a wait command at all.
+func parseWait(name string) (d time.Duration, ok bool, err error) {
+	if !strings.HasPrefix(name, "wait") {
+		return 0, false, nil
+	}
+	arg := name[len("wait"):]
+	if arg == "" {
+		return time.Second, true, nil
+	}
+	if n, err := strconv.ParseUint(arg, 10, 32); err == nil {
+		return time.Duration(n) * time.Second, true, nil
+	}
+	d, err = time.ParseDuration(arg)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid wait command <%s>: %v", name, err)
+	}
+	return d, true, nil
+}
@@ -0,0 +1,45 @@
+package virtualbox
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseKeySequence(t *testing.T) {
+	steps, err := ParseKeySequence("aB<enter><wait5><leftCtrlOn>c<leftCtrlOff><up>", KeymapUS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(steps))
+	}
+
+	want := []byte{0x1e, 0x9e, 0x2a, 0x30, 0xb0, 0xaa, 0x1c, 0x9c}
+	if !bytes.Equal(steps[0].Scancodes, want) {
+		t.Errorf("step 0 scancodes = % x, want % x", steps[0].Scancodes, want)
+	}
+	if steps[0].Wait != 5*time.Second {
+		t.Errorf("step 0 wait = %v, want 5s", steps[0].Wait)
+	}
+
+	want = []byte{0x1d, 0x2e, 0xae, 0x9d, 0xe0, 0x48, 0xe0, 0xc8}
+	if !bytes.Equal(steps[1].Scancodes, want) {
+		t.Errorf("step 1 scancodes = % x, want % x", steps[1].Scancodes, want)
+	}
+}
+
+func TestParseKeySequenceLiteral(t *testing.T) {
+	steps, err := ParseKeySequence("<x>", KeymapUS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x2a, 0x33, 0xb3, 0xaa, 0x2d, 0xad, 0x2a, 0x34, 0xb4, 0xaa}
+	if len(steps) != 1 || !bytes.Equal(steps[0].Scancodes, want) {
+		t.Errorf("got %+v, want % x", steps, want)
+	}
+
+	if _, err := ParseKeySequence("é", KeymapUS); err == nil {
+		t.Error("expected error for unmapped character")
+	}
+}
@@ -0,0 +1,129 @@
+package virtualbox
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// VBoxOUI is the organizationally unique identifier VirtualBox uses for the
+// MAC addresses it generates.
+const VBoxOUI = "080027"
+
+var (
+	ErrMACExhausted = errors.New("no free MAC address found")
+)
+
+// MACAllocator hands out MAC addresses in the VirtualBox OUI that are not used
+// by any NIC or host-only interface known to it. Addresses are formatted like
+// NIC.MACAddress, as 12 upper-case hex digits. It is safe for concurrent use.
+type MACAllocator struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+// NewMACAllocator returns a MACAllocator that avoids the MAC addresses of all
+// NICs of all registered machines and of all host-only interfaces. The NICs of
+// inaccessible machines are unknown; their errors are returned along with the
+// allocator as MachineErrors.
+func NewMACAllocator() (*MACAllocator, error) {
+	ms, err := ListMachines()
+	if _, ok := err.(MachineErrors); err != nil && !ok {
+		return nil, err
+	}
+	a := newMACAllocator()
+	for _, m := range ms {
+		for _, nic := range m.NICs {
+			a.Reserve(nic.MACAddress)
+		}
+	}
+	nets, herr := HostonlyNets()
+	if herr != nil {
+		return nil, herr
+	}
+	for _, n := range nets {
+		a.Reserve(n.HwAddr.String())
+	}
+	return a, err
+}
+
+func newMACAllocator(used ...string) *MACAllocator {
+	a := &MACAllocator{used: map[string]bool{}}
+	for _, mac := range used {
+		a.Reserve(mac)
+	}
+	return a
+}
+
+// normalizeMAC strips separators and upper-cases a MAC address.
+func normalizeMAC(mac string) string {
+	return strings.ToUpper(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
+
+// Reserve marks a MAC address, with or without separators, as used.
+func (a *MACAllocator) Reserve(mac string) {
+	mac = normalizeMAC(mac)
+	if mac == "" {
+		return
+	}
+	a.mu.Lock()
+	a.used[mac] = true
+	a.mu.Unlock()
+}
+
+// InUse reports whether a MAC address is known to be used.
+func (a *MACAllocator) InUse(mac string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.used[normalizeMAC(mac)]
+}
+
+// take reserves VBoxOUI followed by nic and reports whether it was free.
+func (a *MACAllocator) take(nic []byte) (string, bool) {
+	mac := fmt.Sprintf("%s%02X%02X%02X", VBoxOUI, nic[0], nic[1], nic[2])
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.used[mac] {
+		return "", false
+	}
+	a.used[mac] = true
+	return mac, true
+}
+
+// maxMACAttempts bounds the search for a free address.
+const maxMACAttempts = 1000
+
+// Allocate returns a random unused MAC address and reserves it.
+func (a *MACAllocator) Allocate() (string, error) {
+	var b [3]byte
+	for i := 0; i < maxMACAttempts; i++ {
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+		if mac, ok := a.take(b[:]); ok {
+			return mac, nil
+		}
+	}
+	return "", ErrMACExhausted
+}
+
+// AllocateSeeded returns an unused MAC address derived from seed, e.g. a
+// machine name, and reserves it. The same seed yields the same address as long
+// as it is free; otherwise the next free address in a sequence derived from
+// the seed is used.
+func (a *MACAllocator) AllocateSeeded(seed string) (string, error) {
+	for i := 0; i < maxMACAttempts; i++ {
+		s := seed
+		if i > 0 {
+			s = fmt.Sprintf("%s#%d", seed, i)
+		}
+		sum := sha256.Sum256([]byte(s))
+		if mac, ok := a.take(sum[:3]); ok {
+			return mac, nil
+		}
+	}
+	return "", ErrMACExhausted
+}
@@ -0,0 +1,43 @@
+package virtualbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMACAllocator(t *testing.T) {
+	a := newMACAllocator("08:00:27:00:00:01", "080027000002")
+	if !a.InUse("080027000001") || !a.InUse("08-00-27-00-00-02") {
+		t.Error("reserved addresses not in use")
+	}
+
+	mac, err := a.AllocateSeeded("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mac) != 12 || !strings.HasPrefix(mac, VBoxOUI) {
+		t.Errorf("mac = %q", mac)
+	}
+	if again, _ := newMACAllocator().AllocateSeeded("web"); again != mac {
+		t.Errorf("seeded MAC not stable: %q != %q", again, mac)
+	}
+	next, err := a.AllocateSeeded("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == mac {
+		t.Errorf("seeded MAC %q handed out twice", mac)
+	}
+
+	seen := map[string]bool{mac: true, next: true}
+	for i := 0; i < 100; i++ {
+		mac, err := a.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[mac] || !strings.HasPrefix(mac, VBoxOUI) {
+			t.Fatalf("bad or duplicate MAC %q", mac)
+		}
+		seen[mac] = true
+	}
+}
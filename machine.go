@@ -2,10 +2,13 @@ package virtualbox
 
 import (
 	"bufio"
+	"encoding/xml"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,6 +22,31 @@ const (
 	Aborted  = MachineState("aborted")
 )
 
+// onlineStates holds the states in which the machine has a session, so its
+// settings can only be changed with controlvm.
+var onlineStates = map[MachineState]bool{
+	Running:                                    true,
+	Paused:                                     true,
+	MachineState("gurumeditation"):             true,
+	MachineState("starting"):                   true,
+	MachineState("stopping"):                   true,
+	MachineState("saving"):                     true,
+	MachineState("restoring"):                  true,
+	MachineState("teleporting"):                true,
+	MachineState("teleportingpausedvm"):        true,
+	MachineState("teleportingin"):              true,
+	MachineState("livesnapshotting"):           true,
+	MachineState("onlinesnapshotting"):         true,
+	MachineState("deletingsnapshotlive"):       true,
+	MachineState("deletingsnapshotlivepaused"): true,
+}
+
+// online reports whether the machine has a session, i.e. is running, paused
+// or changing between such states.
+func (m *Machine) online() bool {
+	return onlineStates[m.State]
+}
+
 type Flag int
 
 // Flag names in lowercases to be consistent with VBoxManage options.
@@ -55,27 +83,82 @@ func (f Flag) Get(o Flag) string {
 
 // Machine information.
 type Machine struct {
-	Name       string
-	UUID       string
-	State      MachineState
-	CPUs       uint
-	Memory     uint // main memory (in MB)
-	VRAM       uint // video memory (in MB)
-	CfgFile    string
-	BaseFolder string
-	OSType     string
-	Flag       Flag
-	BootOrder  []string // max 4 slots, each in {none|floppy|dvd|disk|net}
-	Usb        UsbController
+	Name             string
+	UUID             string
+	State            MachineState
+	CPUs             uint
+	CPUExecutionCap  uint   // percent of host CPU time per virtual CPU
+	CPUProfile       string // e.g. "host"
+	NestedHWVirt     bool
+	ParavirtProvider ParavirtProvider
+	CPUIDLeaves      []CPUIDLeaf
+	Memory           uint // main memory (in MB)
+	VRAM             uint // video memory (in MB)
+	Graphics         Graphics
+	Chipset          Chipset
+	IOMMU            IOMMU
+	Mouse            PointingDevice
+	Keyboard         KeyboardType
+	CfgFile          string
+	BaseFolder       string
+	OSType           string
+	Firmware         Firmware
+	TPM              TPMType
+	Description      string
+	Flag             Flag
+	BootOrder        []string // max 4 slots, each in {none|floppy|dvd|disk|net}
+	Groups           []string // e.g. "/project/web", RootGroup if ungrouped
+	NICs             []NIC    // NICs[0] is the first NIC
+	Usb              UsbController
+	VRDE             VRDE
+	Recording        Recording
+	SharedFolders    []SharedFolder
+
+	// Inaccessible is set for registered machines whose settings cannot be
+	// loaded, e.g. because the settings file was moved. Only Name, UUID,
+	// CfgFile and AccessError are valid then.
+	Inaccessible bool
+	AccessError  string
+}
+
+// InaccessibleName is the name VirtualBox reports for inaccessible machines.
+const InaccessibleName = "<inaccessible>"
+
+// MachineError records the failure to get the details of one machine.
+type MachineError struct {
+	Ref MachineRef
+	Err error
+}
+
+func (e *MachineError) Error() string {
+	return fmt.Sprintf("machine %q {%s}: %v", e.Ref.Name, e.Ref.UUID, e.Err)
+}
+
+// MachineErrors is returned along with the machines that could be listed when
+// the details of some machines cannot be fetched.
+type MachineErrors []*MachineError
+
+func (e MachineErrors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// id returns the identifier used to address the machine in VBoxManage
+// commands. The UUID is preferred as it stays valid when the machine is
+// renamed.
+func (m *Machine) id() string {
+	if m.UUID != "" {
+		return m.UUID
+	}
+	return m.Name
 }
 
 // Refresh reloads the machine information.
 func (m *Machine) Refresh() error {
-	id := m.Name
-	if id == "" {
-		id = m.UUID
-	}
-	mm, err := GetMachine(id)
+	mm, err := GetMachine(m.id())
 	if err != nil {
 		return err
 	}
@@ -87,9 +170,9 @@ func (m *Machine) Refresh() error {
 func (m *Machine) Start() error {
 	switch m.State {
 	case Paused:
-		return vbm("controlvm", m.Name, "resume")
+		return vbm("controlvm", m.id(), "resume")
 	case Poweroff, Saved, Aborted:
-		return vbm("startvm", m.Name, "--type", "headless")
+		return vbm("startvm", m.id(), "--type", "headless")
 	}
 	return nil
 }
@@ -104,7 +187,7 @@ func (m *Machine) Save() error {
 	case Poweroff, Aborted, Saved:
 		return nil
 	}
-	return vbm("controlvm", m.Name, "savestate")
+	return vbm("controlvm", m.id(), "savestate")
 }
 
 // Pause pauses the execution of the machine.
@@ -113,7 +196,7 @@ func (m *Machine) Pause() error {
 	case Paused, Poweroff, Aborted, Saved:
 		return nil
 	}
-	return vbm("controlvm", m.Name, "pause")
+	return vbm("controlvm", m.id(), "pause")
 }
 
 // Stop gracefully stops the machine.
@@ -128,7 +211,7 @@ func (m *Machine) Stop() error {
 	}
 
 	for m.State != Poweroff { // busy wait until the machine is stopped
-		if err := vbm("controlvm", m.Name, "acpipowerbutton"); err != nil {
+		if err := vbm("controlvm", m.id(), "acpipowerbutton"); err != nil {
 			return err
 		}
 		time.Sleep(1 * time.Second)
@@ -145,7 +228,7 @@ func (m *Machine) Poweroff() error {
 	case Poweroff, Aborted, Saved:
 		return nil
 	}
-	return vbm("controlvm", m.Name, "poweroff")
+	return vbm("controlvm", m.id(), "poweroff")
 }
 
 // Restart gracefully restarts the machine.
@@ -170,7 +253,7 @@ func (m *Machine) Reset() error {
 			return err
 		}
 	}
-	return vbm("controlvm", m.Name, "reset")
+	return vbm("controlvm", m.id(), "reset")
 }
 
 // Delete deletes the machine and associated disk images.
@@ -178,7 +261,60 @@ func (m *Machine) Delete() error {
 	if err := m.Poweroff(); err != nil {
 		return err
 	}
-	return vbm("unregistervm", m.Name, "--delete")
+	return vbm("unregistervm", m.id(), "--delete")
+}
+
+// Unregister unregisters the machine but keeps its settings and disk images,
+// so it can be registered again with RegisterMachine.
+func (m *Machine) Unregister() error {
+	if err := m.Poweroff(); err != nil {
+		return err
+	}
+	return vbm("unregistervm", m.id())
+}
+
+// Move moves the settings and disk images of the machine, which must not be
+// running, into folder. progress, if not nil, is called with the completed
+// percentage while the files are copied.
+func (m *Machine) Move(folder string, progress func(percent int)) error {
+	if m.online() {
+		return fmt.Errorf("cannot move machine %s while it is %s", m.Name, m.State)
+	}
+	if err := vbmProgress(progress, "movevm", m.id(), "--type", "basic", "--folder", folder); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// RegisterMachine registers an existing machine from its .vbox settings file.
+func RegisterMachine(vboxFile string) (*Machine, error) {
+	abs, err := filepath.Abs(vboxFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Machine struct {
+			UUID string `xml:"uuid,attr"`
+		}
+	}
+	err = xml.NewDecoder(f).Decode(&cfg)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", abs, err)
+	}
+	uuid := strings.Trim(cfg.Machine.UUID, "{}")
+	if uuid == "" {
+		return nil, fmt.Errorf("no machine UUID in %s", abs)
+	}
+
+	if err := vbm("registervm", abs); err != nil {
+		return nil, err
+	}
+	return GetMachine(uuid)
 }
 
 // GetMachine finds a machine by its name or UUID.
@@ -192,19 +328,12 @@ func GetMachine(id string) (*Machine, error) {
 	}
 	s := bufio.NewScanner(strings.NewReader(stdout))
 	m := &Machine{}
+	rec := recordingParser{r: &m.Recording}
 	for s.Scan() {
-		res := reVMInfoLine.FindStringSubmatch(s.Text())
-		if res == nil {
+		key, val, ok := splitVMInfoLine(s.Text())
+		if !ok {
 			continue
 		}
-		key := res[1]
-		if key == "" {
-			key = res[2]
-		}
-		val := res[3]
-		if val == "" {
-			val = res[4]
-		}
 
 		switch key {
 		case "name":
@@ -231,6 +360,18 @@ func GetMachine(id string) (*Machine, error) {
 				return nil, err
 			}
 			m.VRAM = uint(n)
+		case "firmware":
+			m.Firmware = Firmware(strings.ToLower(val))
+		case "tpm_type":
+			m.TPM = parseTPMType(val)
+		case "chipset":
+			m.Chipset = Chipset(val)
+		case "iommu":
+			m.IOMMU = IOMMU(val)
+		case "description":
+			m.Description = unescapeVMInfo(val)
+		case "groups":
+			m.Groups = parseGroups(val)
 		case "CfgFile":
 			m.CfgFile = val
 			m.BaseFolder = filepath.Dir(val)
@@ -240,52 +381,191 @@ func GetMachine(id string) (*Machine, error) {
 			m.Usb.UsbType.Ehci = val
 		case "xhci":
 			m.Usb.UsbType.Xhci = val
+		default:
+			if ok, err := m.parseCPU(key, val); ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if ok, err := m.parseNIC(key, val); ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			switch {
+			case m.Graphics.parse(key, val):
+			case m.parseInput(key, val):
+			case m.VRDE.parse(key, val):
+			case rec.parse(key, val):
+			case m.parseSharedFolder(key, val):
+			}
 		}
 	}
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
+	m.Graphics.Accelerate3D = m.Flag&F_accelerate3d == F_accelerate3d
+	if m.Name == InaccessibleName {
+		if err := m.loadAccessError(); err != nil {
+			return nil, err
+		}
+	}
 	return m, nil
 }
 
-// ListMachines lists all registered machines.
-func ListMachines() ([]*Machine, error) {
-	out, err := vbmOut("list", "vms")
+// loadAccessError fills in the settings file and access error of an
+// inaccessible machine, which are only reported in human-readable form.
+func (m *Machine) loadAccessError() error {
+	m.Inaccessible = true
+	stdout, stderr, err := vbmOutErr("showvminfo", m.UUID)
+	if err != nil && stdout == "" {
+		return err
+	}
+	m.CfgFile, m.AccessError = parseAccessError(stdout, stderr)
+	if m.CfgFile != "" {
+		m.BaseFolder = filepath.Dir(m.CfgFile)
+	}
+	return nil
+}
+
+// parseAccessError extracts the settings file and access error details from
+// the human-readable showvminfo output of an inaccessible machine. The details
+// are printed to stderr by some versions of VBoxManage.
+func parseAccessError(stdout, stderr string) (cfgFile, accessErr string) {
+	var details []string
+	inDetails := false
+	for _, line := range strings.Split(stdout+"\n"+stderr, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Config file:"):
+			cfgFile = strings.TrimSpace(line[len("Config file:"):])
+		case line == "Access error details:":
+			inDetails = true
+		case strings.HasPrefix(line, "VBoxManage: error: "):
+			details = append(details, line[len("VBoxManage: error: "):])
+		case inDetails && line != "":
+			details = append(details, line)
+		}
+	}
+	return cfgFile, strings.Join(details, "\n")
+}
+
+// ListConcurrency is the maximum number of machines whose details are fetched
+// in parallel by ListMachines and ListRunningMachines.
+var ListConcurrency = 8
+
+// MachineRef identifies a registered machine without its details.
+type MachineRef struct {
+	Name string
+	UUID string
+}
+
+// ListMachineRefs lists the names and UUIDs of all registered machines.
+func ListMachineRefs() ([]MachineRef, error) {
+	return listMachineRefs("vms")
+}
+
+// ListRunningMachineRefs lists the names and UUIDs of all running machines.
+func ListRunningMachineRefs() ([]MachineRef, error) {
+	return listMachineRefs("runningvms")
+}
+
+func listMachineRefs(kind string) ([]MachineRef, error) {
+	out, err := vbmOut("list", kind)
 	if err != nil {
 		return nil, err
 	}
-	ms := []*Machine{}
+	refs := []MachineRef{}
 	s := bufio.NewScanner(strings.NewReader(out))
 	for s.Scan() {
 		res := reVMNameUUID.FindStringSubmatch(s.Text())
 		if res == nil {
 			continue
 		}
-		m, err := GetMachine(res[1])
-		if err != nil {
-			return nil, err
-		}
-		ms = append(ms, m)
+		refs = append(refs, MachineRef{Name: res[1], UUID: res[2]})
 	}
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
-	return ms, nil
+	return refs, nil
+}
+
+// ListMachines lists all registered machines, including inaccessible ones.
+// If the details of some machines cannot be fetched, the other machines are
+// returned along with a MachineErrors.
+func ListMachines() ([]*Machine, error) {
+	refs, err := ListMachineRefs()
+	if err != nil {
+		return nil, err
+	}
+	return getMachines(refs)
+}
+
+// ListRunningMachines lists all running machines. Errors are reported as in
+// ListMachines.
+func ListRunningMachines() ([]*Machine, error) {
+	refs, err := ListRunningMachineRefs()
+	if err != nil {
+		return nil, err
+	}
+	return getMachines(refs)
 }
 
-// CreateMachine creates a new machine. If basefolder is empty, use default.
-func CreateMachine(name, basefolder string) (*Machine, error) {
+// getMachines fetches the details of the given machines, running at most
+// ListConcurrency showvminfo commands at once. The order of refs is kept.
+// Machines unregistered in the meantime are skipped.
+func getMachines(refs []MachineRef) ([]*Machine, error) {
+	n := ListConcurrency
+	if n < 1 {
+		n = 1
+	}
+	ms := make([]*Machine, len(refs))
+	errs := make([]error, len(refs))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			ms[i], errs[i] = GetMachine(id)
+			<-sem
+		}(i, ref.UUID)
+	}
+	wg.Wait()
+	res := []*Machine{}
+	var merrs MachineErrors
+	for i, err := range errs {
+		switch err {
+		case nil:
+			res = append(res, ms[i])
+		case ErrMachineNotExist:
+		default:
+			merrs = append(merrs, &MachineError{Ref: refs[i], Err: err})
+		}
+	}
+	if merrs != nil {
+		return res, merrs
+	}
+	return res, nil
+}
+
+// CreateMachine creates a new machine in the given groups. If basefolder is
+// empty, use default. If no groups are given, the machine is not grouped.
+func CreateMachine(name, basefolder string, groups ...string) (*Machine, error) {
 	if name == "" {
 		return nil, fmt.Errorf("machine name is empty")
 	}
 
 	// Check if a machine with the given name already exists.
-	ms, err := ListMachines()
+	refs, err := ListMachineRefs()
 	if err != nil {
 		return nil, err
 	}
-	for _, m := range ms {
-		if m.Name == name {
+	for _, ref := range refs {
+		if ref.Name == name {
 			return nil, ErrMachineExist
 		}
 	}
@@ -295,22 +575,71 @@ func CreateMachine(name, basefolder string) (*Machine, error) {
 	if basefolder != "" {
 		args = append(args, "--basefolder", basefolder)
 	}
+	if len(groups) > 0 {
+		args = append(args, "--groups", strings.Join(groups, ","))
+	}
 	if err := vbm(args...); err != nil {
 		return nil, err
 	}
 
 	m, err := GetMachine(name)
 	if err != nil {
+		// Do not leave behind a machine the caller never got hold of.
+		vbm("unregistervm", name, "--delete")
 		return nil, err
 	}
 
 	return m, nil
 }
 
-// Modify changes the settings of the machine.
+// Rename changes the name of the machine.
+func (m *Machine) Rename(name string) error {
+	if name == "" {
+		return fmt.Errorf("machine name is empty")
+	}
+	if err := vbm("modifyvm", m.id(), "--name", name); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetDescription changes the free-form description of the machine.
+func (m *Machine) SetDescription(desc string) error {
+	if err := vbm("modifyvm", m.id(), "--description", desc); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// Clone creates and registers a full clone of the machine with the given
+// name. If no groups are given, the clone is placed in the groups of the
+// machine.
+func (m *Machine) Clone(name string, groups ...string) (*Machine, error) {
+	if name == "" {
+		return nil, fmt.Errorf("machine name is empty")
+	}
+	if len(groups) == 0 {
+		groups = m.Groups
+	}
+	args := []string{"clonevm", m.id(), "--name", name, "--register"}
+	if len(groups) > 0 {
+		args = append(args, "--groups", strings.Join(groups, ","))
+	}
+	if err := vbm(args...); err != nil {
+		return nil, err
+	}
+	return GetMachine(name)
+}
+
+// Modify changes the settings of the machine. The firmware defaults to BIOS
+// if unset.
 func (m *Machine) Modify() error {
-	args := []string{"modifyvm", m.Name,
-		"--firmware", "bios",
+	firmware := m.Firmware
+	if firmware == "" {
+		firmware = FirmwareBIOS
+	}
+	args := []string{"modifyvm", m.id(),
+		"--firmware", string(firmware),
 		"--bioslogofadein", "off",
 		"--bioslogofadeout", "off",
 		"--bioslogodisplaytime", "0",
@@ -351,7 +680,7 @@ func (m *Machine) Modify() error {
 }
 
 func (m *Machine) ModifySimple() error {
-	args := []string{"modifyvm", m.Name,
+	args := []string{"modifyvm", m.id(),
 		"--cpus", fmt.Sprintf("%d", m.CPUs),
 		"--memory", fmt.Sprintf("%d", m.Memory),
 		"--usb", fmt.Sprintf("%s", m.Usb.Usb),
@@ -367,32 +696,83 @@ func (m *Machine) ModifySimple() error {
 
 // AddNATPF adds a NAT port forarding rule to the n-th NIC with the given name.
 func (m *Machine) AddNATPF(n int, name string, rule PFRule) error {
-	return vbm("controlvm", m.Name, fmt.Sprintf("natpf%d", n),
-		fmt.Sprintf("%s,%s", name, rule.Format()))
+	if err := vbm(natpfAddArgs(m.id(), n, name, rule, m.online())...); err != nil {
+		return err
+	}
+	return m.Refresh()
 }
 
 // DelNATPF deletes the NAT port forwarding rule with the given name from the n-th NIC.
 func (m *Machine) DelNATPF(n int, name string) error {
-	return vbm("controlvm", m.Name, fmt.Sprintf("natpf%d", n), "delete", name)
+	if err := vbm(natpfDeleteArgs(m.id(), n, name, m.online())...); err != nil {
+		return err
+	}
+	return m.Refresh()
 }
 
-// SetNIC set the n-th NIC.
-func (m *Machine) SetNIC(n int, nic NIC) error {
-	args := []string{"modifyvm", m.Name,
-		fmt.Sprintf("--nic%d", n), string(nic.Network),
-		fmt.Sprintf("--nictype%d", n), string(nic.Hardware),
-		fmt.Sprintf("--cableconnected%d", n), "on",
+// natpfAddArgs returns the VBoxManage arguments adding a NAT port forwarding
+// rule, using controlvm for running machines and modifyvm otherwise.
+func natpfAddArgs(id string, n int, name string, rule PFRule, running bool) []string {
+	rs := fmt.Sprintf("%s,%s", name, rule.Format())
+	if running {
+		return []string{"controlvm", id, fmt.Sprintf("natpf%d", n), rs}
 	}
+	return []string{"modifyvm", id, fmt.Sprintf("--natpf%d", n), rs}
+}
 
-	if nic.Network == "hostonly" {
-		args = append(args, fmt.Sprintf("--hostonlyadapter%d", n), nic.HostonlyAdapter)
+// natpfDeleteArgs returns the VBoxManage arguments deleting a NAT port
+// forwarding rule, using controlvm for running machines and modifyvm otherwise.
+func natpfDeleteArgs(id string, n int, name string, running bool) []string {
+	if running {
+		return []string{"controlvm", id, fmt.Sprintf("natpf%d", n), "delete", name}
+	}
+	return []string{"modifyvm", id, fmt.Sprintf("--natpf%d", n), "delete", name}
+}
+
+// SetNIC set the n-th NIC. The cable is connected unless
+// nic.CableDisconnected is set. While the machine is running only the network
+// attachment, cable state, promiscuous mode policy and generic driver
+// properties can be changed; other differences yield ErrNICOfflineSetting.
+// Enabling a NIC beyond the chipset limit is an error.
+func (m *Machine) SetNIC(n int, nic NIC) error {
+	if n >= 1 && nic.Network != NICNetAbsent {
+		if err := m.checkChipsetLimits(uint(n), ""); err != nil {
+			return err
+		}
+	}
+	if m.online() {
+		cur := NIC{Network: NICNetAbsent}
+		if n >= 1 && n <= len(m.NICs) {
+			cur = m.NICs[n-1]
+		}
+		cmds, err := nicRunningCmds(m.id(), n, cur, nic)
+		if err != nil {
+			return err
+		}
+		for _, args := range cmds {
+			if err := vbm(args...); err != nil {
+				return err
+			}
+		}
+		return m.Refresh()
+	}
+	if err := vbm(append([]string{"modifyvm", m.id()}, nicArgs(n, nic)...)...); err != nil {
+		return err
 	}
-	return vbm(args...)
+	return m.Refresh()
 }
 
-// AddStorageCtl adds a storage controller with the given name.
+// AddStorageCtl adds a storage controller with the given name. Exceeding the
+// number of controllers per bus the chipset supports is an error.
 func (m *Machine) AddStorageCtl(name string, ctl StorageController) error {
-	args := []string{"storagectl", m.Name, "--name", name}
+	if err := m.checkChipsetLimits(0, ctl.SysBus); err != nil {
+		return err
+	}
+	return vbm(storageCtlArgs(m.id(), name, ctl)...)
+}
+
+func storageCtlArgs(id, name string, ctl StorageController) []string {
+	args := []string{"storagectl", id, "--name", name}
 	if ctl.SysBus != "" {
 		args = append(args, "--add", string(ctl.SysBus))
 	}
@@ -404,20 +784,24 @@ func (m *Machine) AddStorageCtl(name string, ctl StorageController) error {
 	}
 	args = append(args, "--hostiocache", bool2string(ctl.HostIOCache))
 	args = append(args, "--bootable", bool2string(ctl.Bootable))
-	return vbm(args...)
+	return args
 }
 
 // DelStorageCtl deletes the storage controller with the given name.
 func (m *Machine) DelStorageCtl(name string) error {
-	return vbm("storagectl", m.Name, "--name", name, "--remove")
+	return vbm("storagectl", m.id(), "--name", name, "--remove")
 }
 
 // AttachStorage attaches a storage medium to the named storage controller.
 func (m *Machine) AttachStorage(ctlName string, medium StorageMedium) error {
-	return vbm("storageattach", m.Name, "--storagectl", ctlName,
+	return vbm(storageAttachArgs(m.id(), ctlName, medium)...)
+}
+
+func storageAttachArgs(id, ctlName string, medium StorageMedium) []string {
+	return []string{"storageattach", id, "--storagectl", ctlName,
 		"--port", fmt.Sprintf("%d", medium.Port),
 		"--device", fmt.Sprintf("%d", medium.Device),
 		"--type", string(medium.DriveType),
 		"--medium", medium.Medium,
-	)
+	}
 }
@@ -1,6 +1,8 @@
 package virtualbox
 
-import "testing"
+import (
+	"testing"
+)
 
 func TestMachine(t *testing.T) {
 	ms, err := ListMachines()
@@ -11,3 +13,50 @@ func TestMachine(t *testing.T) {
 		t.Logf("%+v", m)
 	}
 }
+
+func TestParseAccessError(t *testing.T) {
+	stdout := `Name:            <inaccessible!>
+UUID:            0c5b3c9d-3f3e-4d1c-9a5e-0a3e7c1f2b4d
+
+Config file:     /vms/old/old.vbox
+Access error details:
+`
+	stderr := `VBoxManage: error: Runtime error opening '/vms/old/old.vbox' for reading: -102 (File not found.).
+VBoxManage: error: Details: code VBOX_E_FILE_ERROR (0x80bb0004), component MachineWrap, interface IMachine
+`
+	cfg, accessErr := parseAccessError(stdout, stderr)
+	if cfg != "/vms/old/old.vbox" {
+		t.Errorf("cfgFile = %q", cfg)
+	}
+	want := "Runtime error opening '/vms/old/old.vbox' for reading: -102 (File not found.).\n" +
+		"Details: code VBOX_E_FILE_ERROR (0x80bb0004), component MachineWrap, interface IMachine"
+	if accessErr != want {
+		t.Errorf("accessErr = %q, want %q", accessErr, want)
+	}
+}
+
+func TestMachineOnline(t *testing.T) {
+	for state, want := range map[MachineState]bool{
+		Running:  true,
+		Paused:   true,
+		"saving": true,
+		Poweroff: false,
+		Saved:    false,
+		Aborted:  false,
+	} {
+		if got := (&Machine{State: state}).online(); got != want {
+			t.Errorf("online() in state %s = %v, want %v", state, got, want)
+		}
+	}
+}
+
+func TestRefreshDeleted(t *testing.T) {
+	useFakeVBM(t, `#!/bin/sh
+echo "VBoxManage: error: Could not find a registered machine with UUID {$2}" >&2
+exit 1
+`)
+	m := &Machine{Name: "web", UUID: "0c5b3c9d-3f3e-4d1c-9a5e-0a3e7c1f2b4d"}
+	if err := m.Refresh(); err != ErrMachineNotExist {
+		t.Errorf("Refresh() = %v, want %v", err, ErrMachineNotExist)
+	}
+}
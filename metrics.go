@@ -0,0 +1,119 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	reMetricLine = regexp.MustCompile(`^(.+?)\s+(\S+/\S+)(?:\s+(.*))?$`)
+)
+
+// MetricSeries holds the samples of one metric collected for one object. The
+// samples are spaced by the collection period and ordered oldest first.
+type MetricSeries struct {
+	Object string // "host" or the name of a machine
+	Metric string // e.g. "CPU/Load/User" or "Guest/RAM/Usage/Total:avg"
+	Unit   string // e.g. "%", "kB", "MHz", "B/s"
+	Values []float64
+}
+
+// Last returns the most recent sample, or 0 if there is none.
+func (s MetricSeries) Last() float64 {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	return s.Values[len(s.Values)-1]
+}
+
+// metricsTarget returns the object and metric list arguments of the metrics
+// subcommands. An empty object means all objects.
+func metricsTarget(object string, metrics []string) []string {
+	if object == "" {
+		object = "*"
+	}
+	args := []string{object}
+	if len(metrics) > 0 {
+		args = append(args, strings.Join(metrics, ","))
+	}
+	return args
+}
+
+// SetupMetrics starts collecting the given metrics (all if none) for object,
+// which is "host", a machine name or UUID, or empty for all objects. Samples
+// are taken every period and up to samples values are retained.
+func SetupMetrics(period time.Duration, samples uint, object string, metrics ...string) error {
+	args := []string{"metrics", "setup",
+		"--period", fmt.Sprintf("%d", int64(period/time.Second)),
+		"--samples", fmt.Sprintf("%d", samples),
+	}
+	args = append(args, metricsTarget(object, metrics)...)
+	return vbm(args...)
+}
+
+// QueryMetrics returns the collected samples of the given metrics (all if
+// none) for object, which is "host", a machine name or UUID, or empty for all
+// objects. Metrics must have been set up with SetupMetrics first.
+func QueryMetrics(object string, metrics ...string) ([]MetricSeries, error) {
+	args := append([]string{"metrics", "query"}, metricsTarget(object, metrics)...)
+	out, err := vbmOut(args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseMetrics(out)
+}
+
+// SetupMetrics starts collecting metrics for the machine. See SetupMetrics.
+func (m *Machine) SetupMetrics(period time.Duration, samples uint, metrics ...string) error {
+	return SetupMetrics(period, samples, m.id(), metrics...)
+}
+
+// QueryMetrics returns the collected metrics of the machine. See QueryMetrics.
+func (m *Machine) QueryMetrics(metrics ...string) ([]MetricSeries, error) {
+	return QueryMetrics(m.id(), metrics...)
+}
+
+// parseMetrics parses the table printed by metrics query.
+func parseMetrics(out string) ([]MetricSeries, error) {
+	series := []MetricSeries{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \r")
+		if strings.HasPrefix(line, "Object ") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		res := reMetricLine.FindStringSubmatch(line)
+		if res == nil {
+			continue
+		}
+		ms := MetricSeries{Object: res[1], Metric: res[2], Values: []float64{}}
+		for _, v := range strings.Split(res[3], ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			i := strings.IndexFunc(v, func(r rune) bool {
+				return !(r >= '0' && r <= '9' || r == '.' || r == '-')
+			})
+			num, unit := v, ""
+			if i >= 0 {
+				num, unit = v[:i], strings.TrimSpace(v[i:])
+			}
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q of metric %s: %v", v, ms.Metric, err)
+			}
+			ms.Unit = unit
+			ms.Values = append(ms.Values, f)
+		}
+		series = append(series, ms)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
@@ -0,0 +1,29 @@
+package virtualbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	out := `Object          Metric                                   Values
+--------------- ---------------------------------------- --------------------------------------------
+host            CPU/Load/User                            1.50%, 2.25%
+host            RAM/Usage/Used                           1024 kB
+my build vm     Guest/RAM/Usage/Total:avg                2048 kB, 4096 kB
+my build vm     Net/Rate/Rx
+`
+	got, err := parseMetrics(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MetricSeries{
+		{Object: "host", Metric: "CPU/Load/User", Unit: "%", Values: []float64{1.5, 2.25}},
+		{Object: "host", Metric: "RAM/Usage/Used", Unit: "kB", Values: []float64{1024}},
+		{Object: "my build vm", Metric: "Guest/RAM/Usage/Total:avg", Unit: "kB", Values: []float64{2048, 4096}},
+		{Object: "my build vm", Metric: "Net/Rate/Rx", Values: []float64{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
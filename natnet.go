@@ -2,6 +2,7 @@ package virtualbox
 
 import (
 	"bufio"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
@@ -14,6 +15,9 @@ type NATNet struct {
 	IPv6    net.IPNet
 	DHCP    bool
 	Enabled bool
+
+	// PortForwards holds the IPv4 port forwarding rules by name.
+	PortForwards map[string]PFRule
 }
 
 // NATNets gets all NAT networks in a  map keyed by NATNet.Name.
@@ -22,16 +26,41 @@ func NATNets() (map[string]NATNet, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseNATNets(out)
+}
+
+func parseNATNets(out string) (map[string]NATNet, error) {
 	s := bufio.NewScanner(strings.NewReader(out))
 	m := map[string]NATNet{}
 	n := NATNet{}
+	inRules := false
 	for s.Scan() {
 		line := s.Text()
 		if line == "" {
 			m[n.Name] = n
 			n = NATNet{}
+			inRules = false
+			continue
+		}
+		// Rules are listed indented below a "Port-forwarding (ipv4)" line.
+		if strings.HasPrefix(line, "Port-forwarding") {
+			inRules = strings.Contains(line, "ipv4")
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if inRules {
+				name, r, err := parseNATNetRule(strings.TrimSpace(line))
+				if err != nil {
+					return nil, err
+				}
+				if n.PortForwards == nil {
+					n.PortForwards = map[string]PFRule{}
+				}
+				n.PortForwards[name] = r
+			}
 			continue
 		}
+		inRules = false
 		res := reColonLine.FindStringSubmatch(line)
 		if res == nil {
 			continue
@@ -51,6 +80,11 @@ func NATNets() (map[string]NATNet, error) {
 			if val == "" {
 				continue
 			}
+			// Newer versions report the whole prefix, e.g. fd17::/64.
+			if _, ipnet, err := net.ParseCIDR(val); err == nil {
+				n.IPv6 = *ipnet
+				continue
+			}
 			l, err := strconv.ParseUint(val, 10, 7)
 			if err != nil {
 				return nil, err
@@ -65,5 +99,22 @@ func NATNets() (map[string]NATNet, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
+	if n.Name != "" {
+		m[n.Name] = n
+	}
 	return m, nil
 }
+
+// parseNATNetRule parses a port forwarding rule as listed by list natnets,
+// e.g. "ssh:tcp:[]:1022:[10.0.2.5]:22".
+func parseNATNetRule(s string) (string, PFRule, error) {
+	f := strings.Split(s, ":")
+	if len(f) != 6 {
+		return "", PFRule{}, fmt.Errorf("invalid port forwarding rule %q", s)
+	}
+	for _, i := range []int{2, 4} {
+		f[i] = strings.TrimSuffix(strings.TrimPrefix(f[i], "["), "]")
+	}
+	r, err := ParsePFRule(strings.Join(f[1:], ","))
+	return f[0], r, err
+}
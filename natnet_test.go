@@ -9,3 +9,33 @@ func TestNATNets(t *testing.T) {
 	}
 	t.Logf("%+v", m)
 }
+
+func TestParseNATNets(t *testing.T) {
+	out := `NetworkName:    NatNetwork
+IP:             10.0.2.1
+Network:        10.0.2.0/24
+IPv6 Enabled:   No
+IPv6 Prefix:    fd17:625c:f037:2::/64
+DHCP Enabled:   Yes
+Enabled:        Yes
+Port-forwarding (ipv4)
+        ssh:tcp:[]:1022:[10.0.2.5]:22
+        dns:udp:[127.0.0.1]:5353:[10.0.2.6]:53
+loopback mappings (ipv4)
+        127.0.0.1=2
+`
+	nets, err := parseNATNets(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ok := nets["NatNetwork"]
+	if !ok || !n.DHCP || len(n.PortForwards) != 2 {
+		t.Fatalf("nets = %+v", nets)
+	}
+	if r := n.PortForwards["ssh"]; r.Format() != "tcp,,1022,10.0.2.5,22" {
+		t.Errorf("ssh rule = %s", r.Format())
+	}
+	if r := n.PortForwards["dns"]; r.Format() != "udp,127.0.0.1,5353,10.0.2.6,53" {
+		t.Errorf("dns rule = %s", r.Format())
+	}
+}
@@ -1,10 +1,40 @@
 package virtualbox
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNICOfflineSetting = errors.New("NIC setting can only be changed while the machine is not running")
+)
+
+var reNICSettingKey = regexp.MustCompile(`^(nic|nictype|nicspeed|cableconnected|macaddress|bridgeadapter|intnet|nat-network|hostonlyadapter|generic|nicgenericdrv|nicpromisc|nicbootprio|nicbandwidthgroup)(\d+)$`)
+
 // NIC represents a virtualized network interface card.
 type NIC struct {
-	Network         NICNetwork
-	Hardware        NICHardware
-	HostonlyAdapter string
+	Network           NICNetwork        `json:"network" yaml:"network"`
+	Hardware          NICHardware       `json:"hardware,omitempty" yaml:"hardware,omitempty"`
+	MACAddress        string            `json:"macAddress,omitempty" yaml:"macAddress,omitempty"` // 12 hex digits, e.g. "080027F3A1B2"
+	CableDisconnected bool              `json:"cableDisconnected,omitempty" yaml:"cableDisconnected,omitempty"`
+	HostonlyAdapter   string            `json:"hostonlyAdapter,omitempty" yaml:"hostonlyAdapter,omitempty"`
+	BridgeAdapter     string            `json:"bridgeAdapter,omitempty" yaml:"bridgeAdapter,omitempty"`
+	InternalNetwork   string            `json:"internalNetwork,omitempty" yaml:"internalNetwork,omitempty"`
+	NATNetwork        string            `json:"natNetwork,omitempty" yaml:"natNetwork,omitempty"`
+	GenericDriver     string            `json:"genericDriver,omitempty" yaml:"genericDriver,omitempty"`
+	Properties        map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"` // generic driver properties
+	Promisc           NICPromisc        `json:"promisc,omitempty" yaml:"promisc,omitempty"`
+	BootPriority      uint              `json:"bootPriority,omitempty" yaml:"bootPriority,omitempty"` // 1 (highest) to 4, 0 for the default
+	Speed             uint              `json:"speed,omitempty" yaml:"speed,omitempty"`               // in kbps, 0 for the default
+	BandwidthGroup    string            `json:"bandwidthGroup,omitempty" yaml:"bandwidthGroup,omitempty"`
+
+	// PortForwards holds the NAT port forwarding rules by name as reported by
+	// GetMachine. SetNIC ignores it; use AddNATPF and DelNATPF.
+	PortForwards map[string]PFRule `json:"portForwards,omitempty" yaml:"portForwards,omitempty"`
 }
 
 // NICNetwork represents the type of NIC networks.
@@ -14,6 +44,7 @@ const (
 	NICNetAbsent       = NICNetwork("none")
 	NICNetDisconnected = NICNetwork("null")
 	NICNetNAT          = NICNetwork("nat")
+	NICNetNATNetwork   = NICNetwork("natnetwork")
 	NICNetBridged      = NICNetwork("bridged")
 	NICNetInternal     = NICNetwork("intnet")
 	NICNetHostonly     = NICNetwork("hostonly")
@@ -31,3 +62,262 @@ const (
 	IntelPro1000MTServer  = NICHardware("82545EM")
 	VirtIO                = NICHardware("virtio")
 )
+
+// NICPromisc represents the promiscuous mode policy of a NIC.
+type NICPromisc string
+
+const (
+	PromiscDeny     = NICPromisc("deny")
+	PromiscAllowVMs = NICPromisc("allow-vms")
+	PromiscAllowAll = NICPromisc("allow-all")
+)
+
+// parseNIC sets the NIC field described by a showvminfo key such as
+// "macaddress2" or "Forwarding(0)", adding NICs to m.NICs as needed. It
+// returns false if the key is not NIC related.
+func (m *Machine) parseNIC(key, val string) (bool, error) {
+	// Forwarding rules follow the settings of the NIC they belong to.
+	if reForwardingKey.MatchString(key) {
+		if len(m.NICs) == 0 {
+			return true, nil
+		}
+		name, r, err := parseNamedPFRule(val)
+		if err != nil {
+			return true, err
+		}
+		nic := &m.NICs[len(m.NICs)-1]
+		if nic.PortForwards == nil {
+			nic.PortForwards = map[string]PFRule{}
+		}
+		nic.PortForwards[name] = r
+		return true, nil
+	}
+	res := reNICSettingKey.FindStringSubmatch(key)
+	if res == nil {
+		return false, nil
+	}
+	n, err := strconv.Atoi(res[2])
+	if err != nil || n < 1 {
+		return false, nil
+	}
+	for len(m.NICs) < n {
+		m.NICs = append(m.NICs, NIC{Network: NICNetAbsent})
+	}
+	nic := &m.NICs[n-1]
+	switch res[1] {
+	case "nic":
+		nic.Network = NICNetwork(val)
+	case "nictype":
+		nic.Hardware = NICHardware(val)
+	case "nicspeed":
+		v, err := strconv.ParseUint(val, 10, 32)
+		if err != nil {
+			return true, err
+		}
+		nic.Speed = uint(v)
+	case "cableconnected":
+		nic.CableDisconnected = (val != "on")
+	case "macaddress":
+		nic.MACAddress = val
+	case "bridgeadapter":
+		nic.BridgeAdapter = val
+	case "intnet":
+		nic.InternalNetwork = val
+	case "nat-network":
+		nic.NATNetwork = val
+	case "hostonlyadapter":
+		nic.HostonlyAdapter = val
+	case "generic":
+		nic.GenericDriver = val
+	case "nicgenericdrv":
+		// Generic driver properties are reported as name=value.
+		if i := strings.Index(val, "="); i > 0 {
+			if nic.Properties == nil {
+				nic.Properties = map[string]string{}
+			}
+			nic.Properties[val[:i]] = val[i+1:]
+		}
+	case "nicpromisc":
+		nic.Promisc = NICPromisc(val)
+	case "nicbootprio":
+		v, err := strconv.ParseUint(val, 10, 32)
+		if err != nil {
+			return true, err
+		}
+		nic.BootPriority = uint(v)
+	case "nicbandwidthgroup":
+		if val != "none" {
+			nic.BandwidthGroup = val
+		}
+	}
+	return true, nil
+}
+
+// nicArgs returns the modifyvm options configuring the n-th NIC.
+func nicArgs(n int, nic NIC) []string {
+	opt := func(name string) string {
+		return fmt.Sprintf("--%s%d", name, n)
+	}
+	args := []string{opt("nic"), string(nic.Network)}
+	if nic.Hardware != "" {
+		args = append(args, opt("nictype"), string(nic.Hardware))
+	}
+	args = append(args, opt("cableconnected"), bool2string(!nic.CableDisconnected))
+	if nic.MACAddress != "" {
+		args = append(args, opt("macaddress"), strings.Replace(nic.MACAddress, ":", "", -1))
+	}
+	switch nic.Network {
+	case NICNetHostonly:
+		args = append(args, opt("hostonlyadapter"), nic.HostonlyAdapter)
+	case NICNetBridged:
+		args = append(args, opt("bridgeadapter"), nic.BridgeAdapter)
+	case NICNetInternal:
+		args = append(args, opt("intnet"), nic.InternalNetwork)
+	case NICNetNATNetwork:
+		args = append(args, opt("nat-network"), nic.NATNetwork)
+	case NICNetGeneric:
+		args = append(args, opt("nicgenericdrv"), nic.GenericDriver)
+		names := make([]string, 0, len(nic.Properties))
+		for name := range nic.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			args = append(args, opt("nicproperty"), name+"="+nic.Properties[name])
+		}
+	}
+	if nic.Promisc != "" {
+		args = append(args, opt("nicpromisc"), string(nic.Promisc))
+	}
+	if nic.BootPriority > 0 {
+		args = append(args, opt("nicbootprio"), fmt.Sprintf("%d", nic.BootPriority))
+	}
+	if nic.Speed > 0 {
+		args = append(args, opt("nicspeed"), fmt.Sprintf("%d", nic.Speed))
+	}
+	if nic.BandwidthGroup != "" {
+		args = append(args, opt("nicbandwidthgroup"), nic.BandwidthGroup)
+	}
+	return args
+}
+
+// nicRunningCmds returns the controlvm invocations changing the n-th NIC of a
+// running machine from cur to nic.
+func nicRunningCmds(id string, n int, cur, nic NIC) ([][]string, error) {
+	if (nic.Hardware != "" && nic.Hardware != cur.Hardware) ||
+		(nic.MACAddress != "" && !strings.EqualFold(strings.Replace(nic.MACAddress, ":", "", -1), cur.MACAddress)) ||
+		(nic.BootPriority > 0 && nic.BootPriority != cur.BootPriority) ||
+		(nic.Speed > 0 && nic.Speed != cur.Speed) ||
+		(nic.BandwidthGroup != "" && nic.BandwidthGroup != cur.BandwidthGroup) {
+		return nil, ErrNICOfflineSetting
+	}
+
+	var cmds [][]string
+	controlvm := func(args ...string) {
+		cmds = append(cmds, append([]string{"controlvm", id}, args...))
+	}
+	attach, param := nicAttachment(nic)
+	if a, p := nicAttachment(cur); attach != a || param != p {
+		if param != "" {
+			controlvm(fmt.Sprintf("nic%d", n), attach, param)
+		} else {
+			controlvm(fmt.Sprintf("nic%d", n), attach)
+		}
+	}
+	if nic.Promisc != "" && nic.Promisc != cur.Promisc {
+		controlvm(fmt.Sprintf("nicpromisc%d", n), string(nic.Promisc))
+	}
+	names := make([]string, 0, len(nic.Properties))
+	for name := range nic.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if val, ok := cur.Properties[name]; ok && val == nic.Properties[name] {
+			continue
+		}
+		controlvm(fmt.Sprintf("nicproperty%d", n), name+"="+nic.Properties[name])
+	}
+	if nic.CableDisconnected != cur.CableDisconnected {
+		controlvm(fmt.Sprintf("setlinkstate%d", n), bool2string(!nic.CableDisconnected))
+	}
+	return cmds, nil
+}
+
+// nicAttachment returns the attachment type and its parameter as accepted by
+// controlvm nicN.
+func nicAttachment(nic NIC) (string, string) {
+	switch nic.Network {
+	case NICNetHostonly:
+		return "hostonly", nic.HostonlyAdapter
+	case NICNetBridged:
+		return "bridged", nic.BridgeAdapter
+	case NICNetInternal:
+		return "intnet", nic.InternalNetwork
+	case NICNetNATNetwork:
+		return "natnetwork", nic.NATNetwork
+	case NICNetGeneric:
+		return "generic", nic.GenericDriver
+	case NICNetAbsent, NICNetDisconnected:
+		return "null", ""
+	}
+	return string(nic.Network), ""
+}
+
+// SetNICCable connects or disconnects the cable of the n-th NIC, live if the
+// machine is running.
+func (m *Machine) SetNICCable(n int, connected bool) error {
+	var err error
+	if m.online() {
+		err = vbm("controlvm", m.id(), fmt.Sprintf("setlinkstate%d", n), bool2string(connected))
+	} else {
+		err = vbm("modifyvm", m.id(), fmt.Sprintf("--cableconnected%d", n), bool2string(connected))
+	}
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetNICPromisc sets the promiscuous mode policy of the n-th NIC, live if the
+// machine is running.
+func (m *Machine) SetNICPromisc(n int, p NICPromisc) error {
+	var err error
+	if m.online() {
+		err = vbm("controlvm", m.id(), fmt.Sprintf("nicpromisc%d", n), string(p))
+	} else {
+		err = vbm("modifyvm", m.id(), fmt.Sprintf("--nicpromisc%d", n), string(p))
+	}
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetNICProperty sets a generic driver property of the n-th NIC, live if the
+// machine is running.
+func (m *Machine) SetNICProperty(n int, name, value string) error {
+	var err error
+	if m.online() {
+		err = vbm("controlvm", m.id(), fmt.Sprintf("nicproperty%d", n), name+"="+value)
+	} else {
+		err = vbm("modifyvm", m.id(), fmt.Sprintf("--nicproperty%d", n), name+"="+value)
+	}
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// SetNICMACAddress sets the MAC address of the n-th NIC, given as 12 hex
+// digits with or without colons, or "auto" for a new random address. The MAC
+// address cannot be changed while the machine is running.
+func (m *Machine) SetNICMACAddress(n int, mac string) error {
+	if m.online() {
+		return ErrNICOfflineSetting
+	}
+	if err := vbm("modifyvm", m.id(), fmt.Sprintf("--macaddress%d", n), strings.Replace(mac, ":", "", -1)); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
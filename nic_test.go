@@ -0,0 +1,94 @@
+package virtualbox
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNIC(t *testing.T) {
+	out := `nic1="nat"
+nictype1="82540EM"
+nicspeed1="0"
+macaddress1="080027F3A1B2"
+cableconnected1="on"
+nic2="bridged"
+nictype2="virtio"
+nicspeed2="1000000"
+bridgeadapter2="eth0"
+macaddress2="080027000001"
+cableconnected2="off"
+nicpromisc2="allow-all"
+nicbootprio2="2"
+nicbandwidthgroup2="slow"
+nic3="generic"
+generic3="UDPTunnel"
+nicgenericdrv3="dest=10.0.0.1"
+nicgenericdrv3="dport=5000"
+nic4="none"
+boot1="disk"
+`
+	m := &Machine{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		key, val, _ := splitVMInfoLine(s.Text())
+		if _, err := m.parseNIC(key, val); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.NICs) != 4 {
+		t.Fatalf("got %d NICs", len(m.NICs))
+	}
+	want := []NIC{
+		{Network: NICNetNAT, Hardware: IntelPro1000MTDesktop, MACAddress: "080027F3A1B2"},
+		{Network: NICNetBridged, Hardware: VirtIO, Speed: 1000000, BridgeAdapter: "eth0", MACAddress: "080027000001",
+			CableDisconnected: true, Promisc: PromiscAllowAll, BootPriority: 2, BandwidthGroup: "slow"},
+		{Network: NICNetGeneric, GenericDriver: "UDPTunnel",
+			Properties: map[string]string{"dest": "10.0.0.1", "dport": "5000"}},
+		{Network: NICNetAbsent},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(m.NICs[i], want[i]) {
+			t.Errorf("NIC %d = %+v, want %+v", i+1, m.NICs[i], want[i])
+		}
+	}
+	args := strings.Join(nicArgs(2, want[1]), " ")
+	if args != "--nic2 bridged --nictype2 virtio --cableconnected2 off --macaddress2 080027000001 --bridgeadapter2 eth0 "+
+		"--nicpromisc2 allow-all --nicbootprio2 2 --nicspeed2 1000000 --nicbandwidthgroup2 slow" {
+		t.Errorf("nicArgs = %s", args)
+	}
+}
+
+func TestNICRunningCmds(t *testing.T) {
+	cur := NIC{Network: NICNetNAT, Hardware: IntelPro1000MTDesktop, MACAddress: "080027F3A1B2", Promisc: PromiscDeny}
+	nic := cur
+	nic.Network = NICNetBridged
+	nic.BridgeAdapter = "eth0"
+	nic.Promisc = PromiscAllowAll
+	nic.CableDisconnected = true
+	cmds, err := nicRunningCmds("vm", 1, cur, nic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range cmds {
+		got = append(got, strings.Join(c, " "))
+	}
+	want := []string{
+		"controlvm vm nic1 bridged eth0",
+		"controlvm vm nicpromisc1 allow-all",
+		"controlvm vm setlinkstate1 off",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cmds = %q, want %q", got, want)
+	}
+	if cmds, _ := nicRunningCmds("vm", 1, cur, cur); len(cmds) != 0 {
+		t.Errorf("unchanged NIC yields %q", cmds)
+	}
+	nic = cur
+	nic.MACAddress = "08:00:27:00:00:01"
+	if _, err := nicRunningCmds("vm", 1, cur, nic); err != ErrNICOfflineSetting {
+		t.Errorf("MAC change on running machine: err = %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Package pcap reads packet capture files in the classic libpcap format, as
+written by the VirtualBox NIC trace (VBoxManage modifyvm --nictrace).
+
+	r, err := pcap.Open("/tmp/nic1.pcap")
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(f.Time, len(f.Data))
+	}
+*/
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	magicMicro = 0xa1b2c3d4
+	magicNano  = 0xa1b23c4d
+)
+
+// LinkTypeEthernet is the link type of captures written by VirtualBox.
+const LinkTypeEthernet = 1
+
+var ErrBadMagic = errors.New("pcap: not a pcap file")
+
+// Header is the global header of a capture file.
+type Header struct {
+	VersionMajor uint16
+	VersionMinor uint16
+	SnapLen      uint32 // maximum length of captured frames
+	LinkType     uint32
+}
+
+// Frame is a captured frame.
+type Frame struct {
+	Time   time.Time
+	Length uint32 // original length on the wire, may exceed len(Data)
+	Data   []byte
+}
+
+// Reader iterates the frames of a capture file.
+type Reader struct {
+	Header Header
+
+	r     io.Reader
+	c     io.Closer
+	order binary.ByteOrder
+	nano  bool
+}
+
+// NewReader reads the global header from r and returns a Reader for the
+// frames that follow.
+func NewReader(r io.Reader) (*Reader, error) {
+	var buf [24]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	pr := &Reader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(buf[0:]) == magicMicro:
+		pr.order = binary.LittleEndian
+	case binary.LittleEndian.Uint32(buf[0:]) == magicNano:
+		pr.order, pr.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(buf[0:]) == magicMicro:
+		pr.order = binary.BigEndian
+	case binary.BigEndian.Uint32(buf[0:]) == magicNano:
+		pr.order, pr.nano = binary.BigEndian, true
+	default:
+		return nil, ErrBadMagic
+	}
+	pr.Header = Header{
+		VersionMajor: pr.order.Uint16(buf[4:]),
+		VersionMinor: pr.order.Uint16(buf[6:]),
+		SnapLen:      pr.order.Uint32(buf[16:]),
+		LinkType:     pr.order.Uint32(buf[20:]),
+	}
+	return pr, nil
+}
+
+// Open opens a capture file. The Reader must be closed after use.
+func Open(name string) (*Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	r.c = f
+	return r, nil
+}
+
+// Close closes the underlying file of a Reader returned by Open.
+func (r *Reader) Close() error {
+	if r.c == nil {
+		return nil
+	}
+	return r.c.Close()
+}
+
+// Next returns the next frame, or io.EOF at the end of the capture. A capture
+// still being written may end in the middle of a frame, which yields
+// io.ErrUnexpectedEOF.
+func (r *Reader) Next() (*Frame, error) {
+	var buf [16]byte
+	if _, err := io.ReadFull(r.r, buf[:]); err != nil {
+		return nil, err
+	}
+	sec := r.order.Uint32(buf[0:])
+	frac := r.order.Uint32(buf[4:])
+	capLen := r.order.Uint32(buf[8:])
+	if r.Header.SnapLen > 0 && capLen > r.Header.SnapLen {
+		return nil, fmt.Errorf("pcap: frame length %d exceeds snapshot length %d", capLen, r.Header.SnapLen)
+	}
+	f := &Frame{Length: r.order.Uint32(buf[12:]), Data: make([]byte, capLen)}
+	if !r.nano {
+		frac *= 1000
+	}
+	f.Time = time.Unix(int64(sec), int64(frac))
+	if _, err := io.ReadFull(r.r, f.Data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadAll returns all remaining frames.
+func (r *Reader) ReadAll() ([]*Frame, error) {
+	var fs []*Frame
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			return fs, nil
+		}
+		if err != nil {
+			return fs, err
+		}
+		fs = append(fs, f)
+	}
+}
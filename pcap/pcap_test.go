@@ -0,0 +1,66 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+)
+
+func capture(order binary.ByteOrder, magic uint32, frames ...[]byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, order, []uint32{magic})
+	binary.Write(&b, order, []uint16{2, 4})
+	binary.Write(&b, order, []uint32{0, 0, 65535, LinkTypeEthernet})
+	for i, f := range frames {
+		binary.Write(&b, order, []uint32{1600000000 + uint32(i), 500, uint32(len(f)), uint32(len(f))})
+		b.Write(f)
+	}
+	return b.Bytes()
+}
+
+func TestReader(t *testing.T) {
+	for _, tc := range []struct {
+		order binary.ByteOrder
+		magic uint32
+		frac  time.Duration
+	}{
+		{binary.LittleEndian, magicMicro, 500 * time.Microsecond},
+		{binary.BigEndian, magicMicro, 500 * time.Microsecond},
+		{binary.LittleEndian, magicNano, 500 * time.Nanosecond},
+	} {
+		data := capture(tc.order, tc.magic, []byte{1, 2, 3}, []byte{4, 5})
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Header.SnapLen != 65535 || r.Header.LinkType != LinkTypeEthernet || r.Header.VersionMajor != 2 {
+			t.Errorf("header = %+v", r.Header)
+		}
+		fs, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fs) != 2 || !bytes.Equal(fs[0].Data, []byte{1, 2, 3}) || fs[1].Length != 2 {
+			t.Fatalf("frames = %+v", fs)
+		}
+		if want := time.Unix(1600000001, 0).Add(tc.frac); !fs[1].Time.Equal(want) {
+			t.Errorf("time = %v, want %v", fs[1].Time, want)
+		}
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	data := capture(binary.LittleEndian, magicMicro, []byte{1, 2, 3})
+	r, err := NewReader(bytes.NewReader(data[:len(data)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err := NewReader(bytes.NewReader(make([]byte, 24))); err != ErrBadMagic {
+		t.Errorf("err = %v, want ErrBadMagic", err)
+	}
+}
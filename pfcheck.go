@@ -0,0 +1,192 @@
+package virtualbox
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PFBinding is a port forwarding rule together with its owner, either a NIC
+// of a machine or a NAT network.
+type PFBinding struct {
+	Machine string // machine name, empty for NAT network rules
+	NIC     int
+	NATNet  string // NAT network name, empty for machine rules
+	Name    string
+	Rule    PFRule
+
+	// Active is set if VirtualBox currently holds the host port, i.e. the
+	// machine or a machine attached to the NAT network is running.
+	Active bool
+}
+
+func (b PFBinding) String() string {
+	if b.NATNet != "" {
+		return fmt.Sprintf("NAT network %s rule %q (%s)", b.NATNet, b.Name, b.Rule)
+	}
+	return fmt.Sprintf("machine %s NIC %d rule %q (%s)", b.Machine, b.NIC, b.Name, b.Rule)
+}
+
+// PFConflict reports port forwarding rules that cannot all be bound: either
+// several rules bind the same host address, port and protocol, or the host
+// port of a single inactive rule is already in use on the host (InUse).
+type PFConflict struct {
+	Bindings []PFBinding
+	InUse    bool
+}
+
+func (c PFConflict) String() string {
+	bs := make([]string, len(c.Bindings))
+	for i, b := range c.Bindings {
+		bs[i] = b.String()
+	}
+	if c.InUse {
+		return fmt.Sprintf("host port in use: %s", strings.Join(bs, ", "))
+	}
+	return fmt.Sprintf("duplicate host binding: %s", strings.Join(bs, ", "))
+}
+
+// PortForwardBindings returns the port forwarding rules of all NICs of all
+// registered machines and of all NAT networks. Errors of inaccessible machines
+// are returned along with the bindings as MachineErrors.
+func PortForwardBindings() ([]PFBinding, error) {
+	ms, err := ListMachines()
+	if _, ok := err.(MachineErrors); err != nil && !ok {
+		return nil, err
+	}
+	nets, nerr := NATNets()
+	if nerr != nil {
+		return nil, nerr
+	}
+	return pfBindings(ms, nets), err
+}
+
+func pfBindings(ms []*Machine, nets map[string]NATNet) []PFBinding {
+	var bs []PFBinding
+	activeNets := map[string]bool{}
+	for _, m := range ms {
+		active := m.online()
+		for i, nic := range m.NICs {
+			if active && nic.Network == NICNetNATNetwork {
+				activeNets[nic.NATNetwork] = true
+			}
+			for _, name := range sortedPFRuleNames(nic.PortForwards) {
+				bs = append(bs, PFBinding{Machine: m.Name, NIC: i + 1, Name: name, Rule: nic.PortForwards[name], Active: active})
+			}
+		}
+	}
+	netNames := make([]string, 0, len(nets))
+	for name := range nets {
+		netNames = append(netNames, name)
+	}
+	sort.Strings(netNames)
+	for _, netName := range netNames {
+		n := nets[netName]
+		for _, name := range sortedPFRuleNames(n.PortForwards) {
+			bs = append(bs, PFBinding{NATNet: n.Name, Name: name, Rule: n.PortForwards[name], Active: activeNets[n.Name]})
+		}
+	}
+	return bs
+}
+
+func sortedPFRuleNames(rules map[string]PFRule) []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckPortForwards validates the port forwarding rules of all machines and
+// NAT networks, so misconfigured rules are found before startvm fails.
+func CheckPortForwards() ([]PFConflict, error) {
+	bs, err := PortForwardBindings()
+	if _, ok := err.(MachineErrors); err != nil && !ok {
+		return nil, err
+	}
+	return findPFConflicts(bs, hostPortInUse), err
+}
+
+// findPFConflicts reports bindings of the same host port and protocol on
+// overlapping addresses, and inactive bindings whose host port inUse reports
+// as taken. Ports held by active bindings are bound by VirtualBox itself and
+// not checked.
+func findPFConflicts(bs []PFBinding, inUse func(PFRule) bool) []PFConflict {
+	type portKey struct {
+		proto PFProto
+		port  uint16
+	}
+	groups := map[portKey][]PFBinding{}
+	var keys []portKey
+	for _, b := range bs {
+		k := portKey{b.Rule.Proto, b.Rule.HostPort}
+		if groups[k] == nil {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], b)
+	}
+
+	var cs []PFConflict
+	for _, k := range keys {
+		g := groups[k]
+		// A rule without host IP binds all addresses and collides with every
+		// other rule on the port; otherwise only equal addresses collide.
+		wildcard := false
+		byIP := map[string][]PFBinding{}
+		var ips []string
+		for _, b := range g {
+			ip := b.Rule.HostIP
+			if ip == nil || ip.IsUnspecified() {
+				wildcard = true
+			}
+			s := ip.String()
+			if byIP[s] == nil {
+				ips = append(ips, s)
+			}
+			byIP[s] = append(byIP[s], b)
+		}
+		if wildcard && len(g) > 1 {
+			cs = append(cs, PFConflict{Bindings: g})
+		} else if !wildcard {
+			for _, ip := range ips {
+				if len(byIP[ip]) > 1 {
+					cs = append(cs, PFConflict{Bindings: byIP[ip]})
+				}
+			}
+		}
+	}
+
+	for _, b := range bs {
+		if !b.Active && inUse(b.Rule) {
+			cs = append(cs, PFConflict{Bindings: []PFBinding{b}, InUse: true})
+		}
+	}
+	return cs
+}
+
+// hostPortInUse reports whether the host address of r cannot be bound.
+func hostPortInUse(r PFRule) bool {
+	host := ""
+	if r.HostIP != nil && !r.HostIP.IsUnspecified() {
+		host = r.HostIP.String()
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(r.HostPort)))
+	switch r.Proto {
+	case PFTCP:
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return true
+		}
+		l.Close()
+	case PFUDP:
+		c, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return true
+		}
+		c.Close()
+	}
+	return false
+}
@@ -3,15 +3,17 @@ package virtualbox
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 )
 
 // PFRule represents a port forwarding rule.
 type PFRule struct {
-	Proto     PFProto
-	HostIP    net.IP // can be nil to match any host interface
-	HostPort  uint16
-	GuestIP   net.IP // can be nil if guest IP is leased from built-in DHCP
-	GuestPort uint16
+	Proto     PFProto `json:"proto" yaml:"proto"`
+	HostIP    net.IP  `json:"hostIP,omitempty" yaml:"hostIP,omitempty"` // can be nil to match any host interface
+	HostPort  uint16  `json:"hostPort" yaml:"hostPort"`
+	GuestIP   net.IP  `json:"guestIP,omitempty" yaml:"guestIP,omitempty"` // can be nil if guest IP is leased from built-in DHCP
+	GuestPort uint16  `json:"guestPort" yaml:"guestPort"`
 }
 
 // PFProto represents the protocol of a port forwarding rule.
@@ -49,3 +51,52 @@ func (r PFRule) Format() string {
 	}
 	return fmt.Sprintf("%s,%s,%d,%s,%d", r.Proto, hostip, r.HostPort, guestip, r.GuestPort)
 }
+
+// ParsePFRule parses a rule in the form returned by Format, e.g.
+// "tcp,,2222,,22". It is the inverse of Format.
+func ParsePFRule(s string) (PFRule, error) {
+	f := strings.Split(s, ",")
+	if len(f) != 5 {
+		return PFRule{}, fmt.Errorf("invalid port forwarding rule %q", s)
+	}
+	var r PFRule
+	switch proto := PFProto(strings.ToLower(f[0])); proto {
+	case PFTCP, PFUDP:
+		r.Proto = proto
+	default:
+		return PFRule{}, fmt.Errorf("invalid port forwarding rule %q: unknown protocol %q", s, f[0])
+	}
+	for _, ip := range []struct {
+		s   string
+		dst *net.IP
+	}{{f[1], &r.HostIP}, {f[3], &r.GuestIP}} {
+		if ip.s == "" {
+			continue
+		}
+		if *ip.dst = net.ParseIP(ip.s); *ip.dst == nil {
+			return PFRule{}, fmt.Errorf("invalid port forwarding rule %q: bad IP %q", s, ip.s)
+		}
+	}
+	for _, p := range []struct {
+		s   string
+		dst *uint16
+	}{{f[2], &r.HostPort}, {f[4], &r.GuestPort}} {
+		n, err := strconv.ParseUint(p.s, 10, 16)
+		if err != nil {
+			return PFRule{}, fmt.Errorf("invalid port forwarding rule %q: bad port %q", s, p.s)
+		}
+		*p.dst = uint16(n)
+	}
+	return r, nil
+}
+
+// parseNamedPFRule parses a rule as reported by showvminfo in Forwarding(N)
+// values, e.g. "ssh,tcp,,2222,,22".
+func parseNamedPFRule(s string) (string, PFRule, error) {
+	i := strings.Index(s, ",")
+	if i < 0 {
+		return "", PFRule{}, fmt.Errorf("invalid port forwarding rule %q", s)
+	}
+	r, err := ParsePFRule(s[i+1:])
+	return s[:i], r, err
+}
@@ -0,0 +1,53 @@
+package virtualbox
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParsePFRule(t *testing.T) {
+	for _, s := range []string{"tcp,,2222,,22", "udp,127.0.0.1,5353,10.0.2.15,53"} {
+		r, err := ParsePFRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Format() != s {
+			t.Errorf("ParsePFRule(%q).Format() = %q", s, r.Format())
+		}
+	}
+	for _, s := range []string{"", "tcp,,2222,,", "icmp,,1,,1", "tcp,host,22,,22", "tcp,,70000,,22"} {
+		if _, err := ParsePFRule(s); err == nil {
+			t.Errorf("ParsePFRule(%q) succeeded", s)
+		}
+	}
+
+	out := `nic1="nat"
+Forwarding(0)="ssh,tcp,,2222,,22"
+Forwarding(1)="dns,udp,127.0.0.1,5353,,53"
+nic2="nat"
+Forwarding(0)="http,tcp,,8080,,80"
+nic3="none"
+`
+	m := &Machine{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		key, val, _ := splitVMInfoLine(s.Text())
+		if _, err := m.parseNIC(key, val); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.NICs) != 3 || len(m.NICs[0].PortForwards) != 2 || len(m.NICs[1].PortForwards) != 1 || m.NICs[2].PortForwards != nil {
+		t.Fatalf("NICs = %+v", m.NICs)
+	}
+	if r := m.NICs[0].PortForwards["dns"]; r.Proto != PFUDP || !r.HostIP.Equal(net.IPv4(127, 0, 0, 1)) || r.HostPort != 5353 || r.GuestPort != 53 {
+		t.Errorf("dns rule = %+v", r)
+	}
+	if r := m.NICs[1].PortForwards["http"]; r.HostPort != 8080 || r.GuestPort != 80 {
+		t.Errorf("http rule = %+v", r)
+	}
+	if got := strings.Join(natpfAddArgs("vm", 1, "ssh", m.NICs[0].PortForwards["ssh"], false), " "); got != "modifyvm vm --natpf1 ssh,tcp,,2222,,22" {
+		t.Errorf("natpfAddArgs = %s", got)
+	}
+}
@@ -0,0 +1,126 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+var (
+	ErrNoFreePort = errors.New("no free host port found")
+)
+
+// forwardPortMu serializes ForwardPort calls within the process, so
+// concurrent callers do not pick the same port before their rules exist.
+var forwardPortMu sync.Mutex
+
+// ForwardPort forwards a free host port to guestPort of the n-th NIC, which
+// must be attached to NAT, and returns the rule. The host port is one the
+// host lets us bind that is not used by a rule of any registered machine or
+// NAT network. The rule is named after the protocol and the ports, e.g.
+// "tcp-22-40123".
+//
+// Other processes may pick the same port at the same time, so the rules are
+// listed again after adding ours, and another port is tried if the port
+// turns out to be shared.
+func (m *Machine) ForwardPort(n int, guestPort uint16, proto PFProto) (PFRule, error) {
+	forwardPortMu.Lock()
+	defer forwardPortMu.Unlock()
+
+	used, err := usedForwardPorts(proto)
+	if err != nil {
+		return PFRule{}, err
+	}
+	for i := 0; i < maxPortAttempts; i++ {
+		port, err := freeHostPort(proto, used)
+		if err != nil {
+			return PFRule{}, err
+		}
+		rule := PFRule{Proto: proto, HostPort: port, GuestPort: guestPort}
+		name := fmt.Sprintf("%s-%d-%d", proto, guestPort, port)
+		if err := m.AddNATPF(n, name, rule); err != nil {
+			return PFRule{}, err
+		}
+		bs, err := PortForwardBindings()
+		if _, ok := err.(MachineErrors); err != nil && !ok {
+			m.DelNATPF(n, name)
+			return PFRule{}, err
+		}
+		if !portClaimed(bs, PFBinding{Machine: m.Name, NIC: n, Name: name, Rule: rule}) {
+			return rule, nil
+		}
+		if err := m.DelNATPF(n, name); err != nil {
+			return PFRule{}, err
+		}
+		used[port] = true
+	}
+	return PFRule{}, ErrNoFreePort
+}
+
+// portClaimed reports whether a binding other than b uses the host port of b.
+func portClaimed(bs []PFBinding, b PFBinding) bool {
+	for _, o := range bs {
+		if o.Rule.Proto == b.Rule.Proto && o.Rule.HostPort == b.Rule.HostPort &&
+			(o.Machine != b.Machine || o.NIC != b.NIC || o.NATNet != b.NATNet || o.Name != b.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// usedForwardPorts returns the host ports of the port forwarding rules for
+// proto of all registered machines and NAT networks.
+func usedForwardPorts(proto PFProto) (map[uint16]bool, error) {
+	bs, err := PortForwardBindings()
+	if _, ok := err.(MachineErrors); err != nil && !ok {
+		return nil, err
+	}
+	used := map[uint16]bool{}
+	for _, b := range bs {
+		if b.Rule.Proto == proto {
+			used[b.Rule.HostPort] = true
+		}
+	}
+	return used, nil
+}
+
+// maxPortAttempts bounds the search for a free host port.
+const maxPortAttempts = 100
+
+// freeHostPort asks the host for an unused port for proto that is not in
+// used.
+func freeHostPort(proto PFProto, used map[uint16]bool) (uint16, error) {
+	for i := 0; i < maxPortAttempts; i++ {
+		port, err := bindFreePort(proto)
+		if err != nil {
+			return 0, err
+		}
+		if !used[port] {
+			return port, nil
+		}
+	}
+	return 0, ErrNoFreePort
+}
+
+// bindFreePort binds an ephemeral port on all interfaces and releases it
+// again.
+func bindFreePort(proto PFProto) (uint16, error) {
+	switch proto {
+	case PFTCP:
+		l, err := net.Listen("tcp", ":0")
+		if err != nil {
+			return 0, err
+		}
+		defer l.Close()
+		return uint16(l.Addr().(*net.TCPAddr).Port), nil
+	case PFUDP:
+		c, err := net.ListenPacket("udp", ":0")
+		if err != nil {
+			return 0, err
+		}
+		defer c.Close()
+		return uint16(c.LocalAddr().(*net.UDPAddr).Port), nil
+	}
+	return 0, fmt.Errorf("unknown protocol %q", proto)
+}
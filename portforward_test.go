@@ -0,0 +1,77 @@
+package virtualbox
+
+import "testing"
+
+func TestFreeHostPort(t *testing.T) {
+	for _, proto := range []PFProto{PFTCP, PFUDP} {
+		port, err := freeHostPort(proto, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port == 0 {
+			t.Errorf("%s port = 0", proto)
+		}
+	}
+	if _, err := freeHostPort(PFProto("sctp"), nil); err == nil {
+		t.Error("free port found for unknown protocol")
+	}
+}
+
+func TestPortClaimed(t *testing.T) {
+	ours := PFBinding{Machine: "web", NIC: 1, Name: "tcp-22-40123", Rule: PFRule{Proto: PFTCP, HostPort: 40123, GuestPort: 22}}
+	bs := []PFBinding{
+		ours,
+		{Machine: "db", NIC: 1, Name: "udp-53-40123", Rule: PFRule{Proto: PFUDP, HostPort: 40123, GuestPort: 53}},
+	}
+	if portClaimed(bs, ours) {
+		t.Error("port claimed by the rule itself or another protocol")
+	}
+	bs = append(bs, PFBinding{Machine: "db", NIC: 1, Name: "tcp-80-40123", Rule: PFRule{Proto: PFTCP, HostPort: 40123, GuestPort: 80}})
+	if !portClaimed(bs, ours) {
+		t.Error("port of another machine not claimed")
+	}
+}
+
+func TestFindPFConflicts(t *testing.T) {
+	rule := func(s string) PFRule {
+		r, err := ParsePFRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	ms := []*Machine{
+		{Name: "a", State: Running, NICs: []NIC{
+			{Network: NICNetNAT, PortForwards: map[string]PFRule{"ssh": rule("tcp,,2222,,22")}},
+			{Network: NICNetNATNetwork, NATNetwork: "net"},
+		}},
+		{Name: "b", State: Poweroff, NICs: []NIC{
+			{Network: NICNetNAT, PortForwards: map[string]PFRule{
+				"ssh":  rule("tcp,127.0.0.1,2222,,22"), // collides with a's wildcard
+				"dns":  rule("udp,,2222,,53"),          // other protocol
+				"web":  rule("tcp,127.0.0.1,8080,,80"),
+				"web2": rule("tcp,127.0.0.2,8080,,80"), // other address
+				"busy": rule("tcp,,9000,,90"),
+			}},
+		}},
+	}
+	nets := map[string]NATNet{
+		"net": {Name: "net", PortForwards: map[string]PFRule{"busy": rule("tcp,,9001,,90")}},
+	}
+	bs := pfBindings(ms, nets)
+	if len(bs) != 7 {
+		t.Fatalf("got %d bindings", len(bs))
+	}
+	inUse := func(r PFRule) bool { return r.HostPort == 9000 || r.HostPort == 9001 }
+	cs := findPFConflicts(bs, inUse)
+	if len(cs) != 2 {
+		t.Fatalf("conflicts = %v", cs)
+	}
+	if cs[0].InUse || len(cs[0].Bindings) != 2 || cs[0].Bindings[0].Machine != "a" || cs[0].Bindings[1].Machine != "b" {
+		t.Errorf("duplicate conflict = %v", cs[0])
+	}
+	// The NAT network is active through machine a, so only b's rule counts.
+	if !cs[1].InUse || cs[1].Bindings[0].Name != "busy" || cs[1].Bindings[0].Machine != "b" {
+		t.Errorf("in-use conflict = %v", cs[1])
+	}
+}
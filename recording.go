@@ -0,0 +1,149 @@
+package virtualbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Recording represents the video/audio recording settings of a machine.
+type Recording struct {
+	Enabled bool
+	Screens []uint // screens to record, nil for all
+	File    string // output file (WebM), empty for the default
+	Width   uint   // video resolution, 0 to keep the current one
+	Height  uint
+	FPS     uint // frames per second, 0 to keep the current value
+	Rate    uint // video bitrate in kbps, 0 to keep the current value
+	MaxTime uint // maximum duration in seconds, 0 for unlimited
+	MaxSize uint // maximum file size in MB, 0 for unlimited
+	Video   bool
+	Audio   bool
+}
+
+// recordingParser sets the fields of a Recording from showvminfo keys.
+type recordingParser struct {
+	r        *Recording
+	screenOn bool // rec_screen_enabled of the screen being parsed
+}
+
+// parse sets the Recording field described by a showvminfo key. It handles
+// both the recording_*/rec_screen_* keys of VirtualBox 6.0+ and the older
+// videocap* keys, and returns false if the key is not recording related.
+func (p *recordingParser) parse(key, val string) bool {
+	r := p.r
+	switch key {
+	case "recording_enabled", "videocap":
+		r.Enabled = (val == "on")
+	case "rec_screen_enabled":
+		p.screenOn = (val == "on")
+	case "rec_screen_id":
+		if n, err := strconv.ParseUint(val, 10, 32); err == nil && p.screenOn {
+			r.Screens = append(r.Screens, uint(n))
+		}
+	case "videocapscreens":
+		r.Screens = nil
+		for _, f := range strings.Split(val, ",") {
+			if n, err := strconv.ParseUint(strings.TrimSpace(f), 10, 32); err == nil {
+				r.Screens = append(r.Screens, uint(n))
+			}
+		}
+	case "rec_screen_dest_filename", "videocapfile":
+		r.File = val
+	case "rec_screen_video_res_xy", "videocapres":
+		fmt.Sscanf(val, "%dx%d", &r.Width, &r.Height)
+	case "rec_screen_video_fps", "videocapfps":
+		if n, err := strconv.ParseUint(val, 10, 32); err == nil {
+			r.FPS = uint(n)
+		}
+	case "rec_screen_video_rate_kbps", "videocaprate":
+		if n, err := strconv.ParseUint(val, 10, 32); err == nil {
+			r.Rate = uint(n)
+		}
+	case "rec_screen_video_enabled":
+		r.Video = (val == "on")
+	case "rec_screen_audio_enabled":
+		r.Audio = (val == "on")
+	case "rec_screen_opts", "videocapopts":
+		for _, opt := range strings.Split(val, ",") {
+			switch opt {
+			case "vc_enabled=true":
+				r.Video = true
+			case "vc_enabled=false":
+				r.Video = false
+			case "ac_enabled=true":
+				r.Audio = true
+			case "ac_enabled=false":
+				r.Audio = false
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// SetRecording changes the recording settings of the machine. While the
+// machine is running only recording itself can be switched on or off. On
+// VirtualBox before 6.0 the equivalent --videocap* options are used.
+func (m *Machine) SetRecording(r Recording) error {
+	major, err := majorVersion()
+	if err != nil {
+		return err
+	}
+	opt := "recording"
+	if major < 6 {
+		opt = "videocap"
+	}
+
+	if m.online() {
+		if err := vbm("controlvm", m.id(), opt, bool2string(r.Enabled)); err != nil {
+			return err
+		}
+		return m.Refresh()
+	}
+
+	screens := "all"
+	if r.Screens != nil {
+		s := make([]string, len(r.Screens))
+		for i, n := range r.Screens {
+			s[i] = fmt.Sprintf("%d", n)
+		}
+		screens = strings.Join(s, ",")
+		if screens == "" {
+			screens = "none"
+		}
+	}
+
+	// VirtualBox 6.0 renamed the options, e.g. --videocapres became
+	// --recordingvideores.
+	video := "video"
+	if major < 6 {
+		video = ""
+	}
+	args := []string{"modifyvm", m.id(),
+		"--" + opt, bool2string(r.Enabled),
+		"--" + opt + "screens", screens,
+		"--" + opt + "maxtime", fmt.Sprintf("%d", r.MaxTime),
+		"--" + opt + "maxsize", fmt.Sprintf("%d", r.MaxSize),
+	}
+	if r.File != "" {
+		args = append(args, "--"+opt+"file", r.File)
+	}
+	if r.Width > 0 && r.Height > 0 {
+		args = append(args, "--"+opt+video+"res", fmt.Sprintf("%dx%d", r.Width, r.Height))
+	}
+	if r.FPS > 0 {
+		args = append(args, "--"+opt+video+"fps", fmt.Sprintf("%d", r.FPS))
+	}
+	if r.Rate > 0 {
+		args = append(args, "--"+opt+video+"rate", fmt.Sprintf("%d", r.Rate))
+	}
+	if major >= 6 {
+		args = append(args, "--recordingopts", fmt.Sprintf("vc_enabled=%t,ac_enabled=%t", r.Video, r.Audio))
+	}
+	if err := vbm(args...); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
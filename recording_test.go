@@ -0,0 +1,62 @@
+package virtualbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordingParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		out  string
+		want Recording
+	}{
+		{"6.1 one screen", `recording_enabled="on"
+recording_screens=1
+ rec_screen0
+rec_screen_enabled="on"
+rec_screen_id=0
+rec_screen_video_enabled="on"
+rec_screen_audio_enabled="off"
+rec_screen_dest="File"
+rec_screen_dest_filename="/vms/web/web-screen0.webm"
+rec_screen_opts="vc_enabled=true,ac_enabled=false,ac_profile=med"
+rec_screen_video_res_xy="1024x768"
+rec_screen_video_rate_kbps=512
+rec_screen_video_fps=25
+`, Recording{
+			Enabled: true, Screens: []uint{0}, File: "/vms/web/web-screen0.webm",
+			Width: 1024, Height: 768, FPS: 25, Rate: 512, Video: true,
+		}},
+		{"6.1 second screen only", `recording_enabled="off"
+recording_screens=2
+ rec_screen0
+rec_screen_enabled="off"
+rec_screen_id=0
+ rec_screen1
+rec_screen_enabled="on"
+rec_screen_id=1
+rec_screen_opts="vc_enabled=false,ac_enabled=true"
+`, Recording{Screens: []uint{1}, Audio: true}},
+		{"5.2 videocap", `videocap="on"
+videocapscreens=0,1
+videocapfile="/vms/web/web.webm"
+videocapres=800x600
+videocaprate=256
+videocapfps=30
+videocapopts="ac_enabled=true"
+`, Recording{
+			Enabled: true, Screens: []uint{0, 1}, File: "/vms/web/web.webm",
+			Width: 800, Height: 600, FPS: 30, Rate: 256, Audio: true,
+		}},
+	} {
+		var r Recording
+		p := recordingParser{r: &r}
+		scanVMInfo(tc.out, func(key, val string) {
+			p.parse(key, val)
+		})
+		if !reflect.DeepEqual(r, tc.want) {
+			t.Errorf("%s: Recording = %+v, want %+v", tc.name, r, tc.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package virtualbox
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"time"
+)
+
+// Screenshot captures the given display (0 for the primary one) of the
+// running machine.
+func (m *Machine) Screenshot(display uint) (image.Image, error) {
+	f, err := os.CreateTemp("", "vbox-screenshot-*.png")
+	if err != nil {
+		return nil, err
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	if err := vbm("controlvm", m.id(), "screenshotpng", name, fmt.Sprintf("%d", display)); err != nil {
+		return nil, err
+	}
+
+	f, err = os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// WaitScreenStable captures the primary display every interval until two
+// successive captures are identical, and returns the last capture. It gives
+// up when ctx is done.
+func (m *Machine) WaitScreenStable(ctx context.Context, interval time.Duration) (image.Image, error) {
+	prev, err := m.Screenshot(0)
+	if err != nil {
+		return nil, err
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return prev, ctx.Err()
+		case <-t.C:
+		}
+		img, err := m.Screenshot(0)
+		if err != nil {
+			return nil, err
+		}
+		if SameImage(prev, img) {
+			return img, nil
+		}
+		prev = img
+	}
+}
+
+// SameImage reports whether a and b have the same bounds and pixels.
+func SameImage(a, b image.Image) bool {
+	r := a.Bounds()
+	if r != b.Bounds() {
+		return false
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			r1, g1, b1, a1 := a.At(x, y).RGBA()
+			r2, g2, b2, a2 := b.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package virtualbox
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSameImage(t *testing.T) {
+	fill := func(r image.Rectangle) *image.RGBA {
+		img := image.NewRGBA(r)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Set(x, y, color.RGBA{0, 0, 128, 255})
+			}
+		}
+		return img
+	}
+	bounds := image.Rect(0, 0, 4, 3)
+
+	a, b := fill(bounds), fill(bounds)
+	if !SameImage(a, b) {
+		t.Error("equal images differ")
+	}
+	// The same pixels in another color model are still the same image.
+	if !SameImage(a, toNRGBA(a)) {
+		t.Error("equal images in different color models differ")
+	}
+
+	b.Set(3, 2, color.RGBA{0, 0, 129, 255})
+	if SameImage(a, b) {
+		t.Error("images with a different pixel are the same")
+	}
+
+	if SameImage(a, fill(image.Rect(0, 0, 4, 4))) {
+		t.Error("images with different bounds are the same")
+	}
+	if SameImage(a, fill(bounds.Add(image.Pt(1, 0)))) {
+		t.Error("images with shifted bounds are the same")
+	}
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	r := img.Bounds()
+	n := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			n.Set(x, y, img.At(x, y))
+		}
+	}
+	return n
+}
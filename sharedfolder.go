@@ -0,0 +1,64 @@
+package virtualbox
+
+import (
+	"strings"
+)
+
+// SharedFolder represents a host folder shared with the guest.
+type SharedFolder struct {
+	Name       string `json:"name" yaml:"name"`
+	HostPath   string `json:"hostPath" yaml:"hostPath"`
+	ReadOnly   bool   `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	AutoMount  bool   `json:"autoMount,omitempty" yaml:"autoMount,omitempty"`
+	MountPoint string `json:"mountPoint,omitempty" yaml:"mountPoint,omitempty"` // guest path for auto-mounting
+}
+
+// parseSharedFolder sets the shared folder described by a showvminfo key. Only
+// permanent (machine) shared folders are reported; read-only and auto-mount
+// settings are not part of the output. It returns false if the key is not
+// shared folder related.
+func (m *Machine) parseSharedFolder(key, val string) bool {
+	switch {
+	case strings.HasPrefix(key, "SharedFolderNameMachineMapping"):
+		m.SharedFolders = append(m.SharedFolders, SharedFolder{Name: val})
+	case strings.HasPrefix(key, "SharedFolderPathMachineMapping"):
+		if n := len(m.SharedFolders); n > 0 {
+			m.SharedFolders[n-1].HostPath = val
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// sharedFolderAddArgs returns the VBoxManage arguments adding the shared
+// folder to the machine with the given id.
+func sharedFolderAddArgs(id string, f SharedFolder) []string {
+	args := []string{"sharedfolder", "add", id, "--name", f.Name, "--hostpath", f.HostPath}
+	if f.ReadOnly {
+		args = append(args, "--readonly")
+	}
+	if f.AutoMount {
+		args = append(args, "--automount")
+	}
+	if f.MountPoint != "" {
+		args = append(args, "--auto-mount-point", f.MountPoint)
+	}
+	return args
+}
+
+// AddSharedFolder shares a host folder with the machine permanently.
+func (m *Machine) AddSharedFolder(f SharedFolder) error {
+	if err := vbm(sharedFolderAddArgs(m.id(), f)...); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// RemoveSharedFolder removes the shared folder with the given name.
+func (m *Machine) RemoveSharedFolder(name string) error {
+	if err := vbm("sharedfolder", "remove", m.id(), "--name", name); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
@@ -0,0 +1,326 @@
+package virtualbox
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/xshellinc/go-virtualbox/vboxconfig"
+)
+
+var (
+	reForwardingKey = regexp.MustCompile(`^Forwarding\(\d+\)$`)
+)
+
+// MachineSpec declares the desired settings of a machine. Zero values mean
+// "leave as is", so a spec only needs to mention the settings it cares about.
+// The exception are the HostIOCache and Bootable settings of declared storage
+// controllers, which are always applied.
+type MachineSpec struct {
+	Name               string                  `json:"name" yaml:"name"`
+	Groups             []string                `json:"groups,omitempty" yaml:"groups,omitempty"`
+	OSType             string                  `json:"ostype,omitempty" yaml:"ostype,omitempty"`
+	CPUs               uint                    `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	Memory             uint                    `json:"memory,omitempty" yaml:"memory,omitempty"` // in MB
+	VRAM               uint                    `json:"vram,omitempty" yaml:"vram,omitempty"`     // in MB
+	Firmware           Firmware                `json:"firmware,omitempty" yaml:"firmware,omitempty"`
+	Flags              map[string]bool         `json:"flags,omitempty" yaml:"flags,omitempty"` // modifyvm on/off options, e.g. "ioapic"
+	BootOrder          []string                `json:"bootOrder,omitempty" yaml:"bootOrder,omitempty"`
+	NICs               []NIC                   `json:"nics,omitempty" yaml:"nics,omitempty"` // NICs[0] is the first NIC
+	StorageControllers []StorageControllerSpec `json:"storageControllers,omitempty" yaml:"storageControllers,omitempty"`
+	PortForwards       []PortForwardSpec       `json:"portForwards,omitempty" yaml:"portForwards,omitempty"`
+	SharedFolders      []SharedFolder          `json:"sharedFolders,omitempty" yaml:"sharedFolders,omitempty"`
+}
+
+// StorageControllerSpec declares a storage controller and its attachments.
+type StorageControllerSpec struct {
+	StorageController `yaml:",inline"`
+
+	Name        string          `json:"name" yaml:"name"`
+	Attachments []StorageMedium `json:"attachments,omitempty" yaml:"attachments,omitempty"`
+}
+
+// PortForwardSpec declares a named NAT port forwarding rule on the n-th NIC.
+type PortForwardSpec struct {
+	PFRule `yaml:",inline"`
+
+	NIC  int    `json:"nic" yaml:"nic"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// Operation is a single VBoxManage invocation planned to converge a machine
+// towards a spec.
+type Operation struct {
+	Description string
+	Args        []string
+}
+
+func (o Operation) String() string {
+	return fmt.Sprintf("%s: VBoxManage %s", o.Description, strings.Join(o.Args, " "))
+}
+
+// Plan returns the operations needed to bring the machine in line with spec.
+// Settings, NICs, controllers, attachments, rules and shared folders not
+// mentioned in spec are left untouched, and an empty plan means the machine
+// already matches.
+func (m *Machine) Plan(spec MachineSpec) ([]Operation, error) {
+	out, err := vbmOut("showvminfo", m.id(), "--machinereadable")
+	if err != nil {
+		return nil, err
+	}
+	cur, err := parseVMSettings(out)
+	if err != nil {
+		return nil, err
+	}
+	// showvminfo only reports the OS type description and not the host I/O
+	// cache setting; the settings file has both.
+	if cfg, err := vboxconfig.ReadMachine(m.CfgFile); err == nil {
+		cur.osType = cfg.Machine.OSType
+		for _, ctl := range cfg.Machine.StorageControllers() {
+			cur.hostIOCache[ctl.Name] = ctl.HostIOCache
+		}
+	}
+	return planSpec(m.id(), spec, cur, m.online()), nil
+}
+
+// Apply executes the operations planned for spec. Running it again once it
+// succeeded does nothing.
+func (m *Machine) Apply(spec MachineSpec) error {
+	ops, err := m.Plan(spec)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if err := vbm(op.Args...); err != nil {
+			return fmt.Errorf("%s: %v", op.Description, err)
+		}
+	}
+	return m.Refresh()
+}
+
+// ApplySpec creates the machine named in spec if it does not exist yet and
+// applies spec to it.
+func ApplySpec(spec MachineSpec) (*Machine, error) {
+	m, err := GetMachine(spec.Name)
+	if err == ErrMachineNotExist {
+		m, err = CreateMachine(spec.Name, "", spec.Groups...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Apply(spec); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// vmSettings holds the raw settings reported by showvminfo --machinereadable,
+// completed with settings only found in the settings file.
+type vmSettings struct {
+	vals        map[string]string
+	nics        []NIC           // as parsed by Machine.parseNIC
+	osType      string          // OS type ID, empty if unknown
+	hostIOCache map[string]bool // by controller name, missing if unknown
+}
+
+func parseVMSettings(out string) (*vmSettings, error) {
+	vs := &vmSettings{vals: map[string]string{}, hostIOCache: map[string]bool{}}
+	m := &Machine{}
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		key, val, ok := splitVMInfoLine(s.Text())
+		if !ok {
+			continue
+		}
+		vs.vals[key] = val
+		if _, err := m.parseNIC(key, val); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	vs.nics = m.NICs
+	return vs, nil
+}
+
+// nic returns the n-th NIC, or an absent one.
+func (vs *vmSettings) nic(n int) NIC {
+	if n >= 1 && n <= len(vs.nics) {
+		return vs.nics[n-1]
+	}
+	return NIC{Network: NICNetAbsent}
+}
+
+// nicDiff returns the modifyvm options changing the n-th NIC from cur to nic.
+// An empty network keeps the current one along with its unset settings.
+func nicDiff(n int, cur, nic NIC) []string {
+	keep := nic.Network == ""
+	if keep {
+		nic.Network = cur.Network
+	}
+	// Generic driver properties share an option, so key them by name.
+	key := func(opt, val string) string {
+		if opt == fmt.Sprintf("--nicproperty%d", n) {
+			return opt + " " + strings.SplitN(val, "=", 2)[0]
+		}
+		return opt
+	}
+	have := map[string]string{}
+	ca := nicArgs(n, cur)
+	for i := 0; i+1 < len(ca); i += 2 {
+		have[key(ca[i], ca[i+1])] = ca[i+1]
+	}
+	var args []string
+	na := nicArgs(n, nic)
+	for i := 0; i+1 < len(na); i += 2 {
+		opt, val := na[i], na[i+1]
+		if keep && val == "" {
+			continue
+		}
+		if v, ok := have[key(opt, val)]; ok && (v == val || opt == fmt.Sprintf("--macaddress%d", n) && strings.EqualFold(v, val)) {
+			continue
+		}
+		args = append(args, opt, val)
+	}
+	return args
+}
+
+func planSpec(id string, spec MachineSpec, cur *vmSettings, running bool) []Operation {
+	var ops []Operation
+	get := func(format string, a ...interface{}) string {
+		return cur.vals[fmt.Sprintf(format, a...)]
+	}
+
+	if spec.Name != "" && spec.Name != cur.vals["name"] {
+		ops = append(ops, Operation{"rename machine", []string{"modifyvm", id, "--name", spec.Name}})
+	}
+	if len(spec.Groups) > 0 && strings.Join(spec.Groups, ",") != cur.vals["groups"] {
+		ops = append(ops, Operation{"set groups", []string{"modifyvm", id, "--groups", strings.Join(spec.Groups, ",")}})
+	}
+
+	var mod []string
+	if spec.OSType != "" && spec.OSType != cur.osType {
+		mod = append(mod, "--ostype", spec.OSType)
+	}
+	for _, s := range []struct {
+		opt string
+		val uint
+	}{{"cpus", spec.CPUs}, {"memory", spec.Memory}, {"vram", spec.VRAM}} {
+		if v := fmt.Sprintf("%d", s.val); s.val > 0 && v != cur.vals[s.opt] {
+			mod = append(mod, "--"+s.opt, v)
+		}
+	}
+	if spec.Firmware != "" && !strings.EqualFold(string(spec.Firmware), cur.vals["firmware"]) {
+		mod = append(mod, "--firmware", strings.ToLower(string(spec.Firmware)))
+	}
+	flags := make([]string, 0, len(spec.Flags))
+	for f := range spec.Flags {
+		flags = append(flags, f)
+	}
+	sort.Strings(flags)
+	for _, f := range flags {
+		if v := bool2string(spec.Flags[f]); v != cur.vals[f] {
+			mod = append(mod, "--"+f, v)
+		}
+	}
+	if len(spec.BootOrder) > 0 {
+		for i := 0; i < 4; i++ {
+			dev := "none"
+			if i < len(spec.BootOrder) {
+				dev = spec.BootOrder[i]
+			}
+			if dev != get("boot%d", i+1) {
+				mod = append(mod, fmt.Sprintf("--boot%d", i+1), dev)
+			}
+		}
+	}
+	if len(mod) > 0 {
+		ops = append(ops, Operation{"modify settings", append([]string{"modifyvm", id}, mod...)})
+	}
+
+	for i, nic := range spec.NICs {
+		n := i + 1
+		if args := nicDiff(n, cur.nic(n), nic); len(args) > 0 {
+			ops = append(ops, Operation{fmt.Sprintf("configure NIC %d", n), append([]string{"modifyvm", id}, args...)})
+		}
+	}
+
+	ctls := map[string]int{}
+	for i := 0; ; i++ {
+		name, ok := cur.vals[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			break
+		}
+		ctls[name] = i
+	}
+	for _, cs := range spec.StorageControllers {
+		i, ok := ctls[cs.Name]
+		if !ok {
+			ops = append(ops, Operation{fmt.Sprintf("add storage controller %q", cs.Name), storageCtlArgs(id, cs.Name, cs.StorageController)})
+		} else {
+			var args []string
+			if cs.Ports > 0 && fmt.Sprintf("%d", cs.Ports) != get("storagecontrollerportcount%d", i) {
+				args = append(args, "--portcount", fmt.Sprintf("%d", cs.Ports))
+			}
+			if cs.Chipset != "" && !strings.EqualFold(string(cs.Chipset), get("storagecontrollertype%d", i)) {
+				args = append(args, "--controller", string(cs.Chipset))
+			}
+			if cache, ok := cur.hostIOCache[cs.Name]; !ok || cache != cs.HostIOCache {
+				args = append(args, "--hostiocache", bool2string(cs.HostIOCache))
+			}
+			if bool2string(cs.Bootable) != get("storagecontrollerbootable%d", i) {
+				args = append(args, "--bootable", bool2string(cs.Bootable))
+			}
+			if len(args) > 0 {
+				args = append([]string{"storagectl", id, "--name", cs.Name}, args...)
+				ops = append(ops, Operation{fmt.Sprintf("modify storage controller %q", cs.Name), args})
+			}
+		}
+		for _, a := range cs.Attachments {
+			medium := get("%s-%d-%d", cs.Name, a.Port, a.Device)
+			uuid := get("%s-ImageUUID-%d-%d", cs.Name, a.Port, a.Device)
+			if a.Medium == medium || a.Medium == uuid || (medium != "" && filepath.Clean(a.Medium) == filepath.Clean(medium)) {
+				continue
+			}
+			ops = append(ops, Operation{
+				fmt.Sprintf("attach %s to %q port %d device %d", a.Medium, cs.Name, a.Port, a.Device),
+				storageAttachArgs(id, cs.Name, a),
+			})
+		}
+	}
+
+	for _, pf := range spec.PortForwards {
+		have, ok := cur.nic(pf.NIC).PortForwards[pf.Name]
+		if ok && have.Format() == pf.Format() {
+			continue
+		}
+		if ok {
+			ops = append(ops, Operation{fmt.Sprintf("delete port forwarding %q on NIC %d", pf.Name, pf.NIC), natpfDeleteArgs(id, pf.NIC, pf.Name, running)})
+		}
+		ops = append(ops, Operation{fmt.Sprintf("add port forwarding %q on NIC %d", pf.Name, pf.NIC), natpfAddArgs(id, pf.NIC, pf.Name, pf.PFRule, running)})
+	}
+
+	folders := map[string]string{}
+	for i := 1; ; i++ {
+		name, ok := cur.vals[fmt.Sprintf("SharedFolderNameMachineMapping%d", i)]
+		if !ok {
+			break
+		}
+		folders[name] = get("SharedFolderPathMachineMapping%d", i)
+	}
+	for _, f := range spec.SharedFolders {
+		path, ok := folders[f.Name]
+		if ok && path == f.HostPath {
+			continue
+		}
+		if ok {
+			ops = append(ops, Operation{fmt.Sprintf("remove shared folder %q", f.Name), []string{"sharedfolder", "remove", id, "--name", f.Name}})
+		}
+		ops = append(ops, Operation{fmt.Sprintf("add shared folder %q", f.Name), sharedFolderAddArgs(id, f)})
+	}
+	return ops
+}
@@ -0,0 +1,133 @@
+package virtualbox
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const specVMInfo = `name="web"
+groups="/"
+cpus=1
+memory=1024
+vram=16
+firmware="BIOS"
+ioapic="on"
+boot1="dvd"
+boot2="disk"
+boot3="none"
+boot4="none"
+nic1="nat"
+nictype1="82540EM"
+Forwarding(0)="ssh,tcp,,2222,,22"
+Forwarding(1)="web,tcp,,8080,,80"
+nic2="none"
+storagecontrollername0="SATA"
+storagecontrollertype0="IntelAhci"
+storagecontrollerportcount0="1"
+storagecontrollerbootable0="on"
+"SATA-0-0"="/vms/web/web.vdi"
+"SATA-ImageUUID-0-0"="a1b2c3d4-0000-4000-8000-000000000001"
+SharedFolderNameMachineMapping1="src"
+SharedFolderPathMachineMapping1="/home/ci/src"
+`
+
+func TestPlanSpec(t *testing.T) {
+	spec := MachineSpec{
+		Name:      "web",
+		OSType:    "Ubuntu_64",
+		CPUs:      2,
+		Memory:    1024,
+		Flags:     map[string]bool{"ioapic": true, "pae": false},
+		BootOrder: []string{"dvd", "disk"},
+		NICs: []NIC{
+			{Network: NICNetNAT, Hardware: IntelPro1000MTDesktop},
+			{Network: NICNetHostonly, HostonlyAdapter: "vboxnet0"},
+			{Hardware: VirtIO},
+		},
+		StorageControllers: []StorageControllerSpec{
+			{
+				StorageController: StorageController{SysBus: SysBusSATA, Ports: 1, Chipset: CtrlIntelAHCI, Bootable: true},
+				Name:              "SATA",
+				Attachments: []StorageMedium{
+					{Port: 0, Device: 0, DriveType: DriveHDD, Medium: "a1b2c3d4-0000-4000-8000-000000000001"},
+					{Port: 0, Device: 1, DriveType: DriveDVD, Medium: "/isos/ubuntu.iso"},
+				},
+			},
+		},
+		PortForwards: []PortForwardSpec{
+			{NIC: 1, Name: "ssh", PFRule: PFRule{Proto: PFTCP, HostPort: 2222, GuestPort: 22}},
+			{NIC: 1, Name: "web", PFRule: PFRule{Proto: PFTCP, HostPort: 8081, GuestPort: 80}},
+		},
+		SharedFolders: []SharedFolder{{Name: "src", HostPath: "/home/ci/src"}},
+	}
+	cur, err := parseVMSettings(specVMInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur.osType = "Ubuntu_64"
+	cur.hostIOCache["SATA"] = true
+	ops := planSpec("uuid", spec, cur, false)
+
+	want := [][]string{
+		{"modifyvm", "uuid", "--cpus", "2", "--pae", "off"},
+		{"modifyvm", "uuid", "--nic2", "hostonly", "--hostonlyadapter2", "vboxnet0"},
+		{"modifyvm", "uuid", "--nictype3", "virtio"},
+		{"storagectl", "uuid", "--name", "SATA", "--hostiocache", "off"},
+		{"storageattach", "uuid", "--storagectl", "SATA", "--port", "0", "--device", "1", "--type", "dvddrive", "--medium", "/isos/ubuntu.iso"},
+		{"modifyvm", "uuid", "--natpf1", "delete", "web"},
+		{"modifyvm", "uuid", "--natpf1", "web,tcp,,8081,,80"},
+	}
+	var got [][]string
+	for _, op := range ops {
+		got = append(got, op.Args)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestNICDiff(t *testing.T) {
+	cur := NIC{
+		Network:       NICNetGeneric,
+		Hardware:      VirtIO,
+		MACAddress:    "080027F3A1B2",
+		GenericDriver: "UDPTunnel",
+		Properties:    map[string]string{"dest": "10.0.0.1", "sport": "10001"},
+	}
+	for _, tc := range []struct {
+		nic  NIC
+		want []string
+	}{
+		{NIC{Network: NICNetGeneric, Hardware: VirtIO, MACAddress: "08:00:27:f3:a1:b2", GenericDriver: "UDPTunnel"}, nil},
+		{NIC{Hardware: VirtIO, CableDisconnected: true}, []string{"--cableconnected2", "off"}},
+		{NIC{Promisc: PromiscAllowAll, Speed: 1000}, []string{"--nicpromisc2", "allow-all", "--nicspeed2", "1000"}},
+		{
+			NIC{Network: NICNetGeneric, Hardware: VirtIO, GenericDriver: "UDPTunnel", Properties: map[string]string{"dest": "10.0.0.2", "sport": "10001"}},
+			[]string{"--nicproperty2", "dest=10.0.0.2"},
+		},
+		{
+			NIC{Network: NICNetBridged, Hardware: VirtIO, BridgeAdapter: "eth0"},
+			[]string{"--nic2", "bridged", "--bridgeadapter2", "eth0"},
+		},
+	} {
+		if got := nicDiff(2, cur, tc.nic); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("nicDiff(%+v) = %q, want %q", tc.nic, got, tc.want)
+		}
+	}
+}
+
+func TestMachineSpecJSON(t *testing.T) {
+	in := `{"name":"web","cpus":2,"storageControllers":[{"name":"SATA","bus":"sata","ports":1}],
+		"portForwards":[{"nic":1,"name":"ssh","proto":"tcp","hostPort":2222,"guestPort":22}]}`
+	var spec MachineSpec
+	if err := json.Unmarshal([]byte(in), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.StorageControllers[0].SysBus != SysBusSATA || spec.StorageControllers[0].Ports != 1 {
+		t.Errorf("storage controllers = %+v", spec.StorageControllers)
+	}
+	if pf := spec.PortForwards[0]; pf.Name != "ssh" || pf.HostPort != 2222 || pf.Proto != PFTCP {
+		t.Errorf("port forwards = %+v", spec.PortForwards)
+	}
+}
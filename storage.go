@@ -2,11 +2,11 @@ package virtualbox
 
 // StorageController represents a virtualized storage controller.
 type StorageController struct {
-	SysBus      SystemBus
-	Ports       uint // SATA port count 1--30
-	Chipset     StorageControllerChipset
-	HostIOCache bool
-	Bootable    bool
+	SysBus      SystemBus                `json:"bus" yaml:"bus"`
+	Ports       uint                     `json:"ports,omitempty" yaml:"ports,omitempty"` // SATA port count 1--30
+	Chipset     StorageControllerChipset `json:"chipset,omitempty" yaml:"chipset,omitempty"`
+	HostIOCache bool                     `json:"hostIOCache,omitempty" yaml:"hostIOCache,omitempty"`
+	Bootable    bool                     `json:"bootable,omitempty" yaml:"bootable,omitempty"`
 }
 
 // SystemBus represents the system bus of a storage controller.
@@ -17,6 +17,9 @@ const (
 	SysBusSATA   = SystemBus("sata")
 	SysBusSCSI   = SystemBus("scsi")
 	SysBusFloppy = SystemBus("floppy")
+	SysBusSAS    = SystemBus("sas")
+	SysBusPCIe   = SystemBus("pcie")
+	SysBusVirtIO = SystemBus("virtio")
 )
 
 // StorageControllerChipset represents the hardware of a storage controller.
@@ -35,10 +38,10 @@ const (
 
 // StorageMedium represents the storage medium attached to a storage controller.
 type StorageMedium struct {
-	Port      uint
-	Device    uint
-	DriveType DriveType
-	Medium    string // none|emptydrive|<uuid>|<filename|host:<drive>|iscsi
+	Port      uint      `json:"port" yaml:"port"`
+	Device    uint      `json:"device" yaml:"device"`
+	DriveType DriveType `json:"type" yaml:"type"`
+	Medium    string    `json:"medium" yaml:"medium"` // none|emptydrive|<uuid>|<filename|host:<drive>|iscsi
 }
 
 // DriveType represents the hardware type of a drive.
@@ -0,0 +1,33 @@
+package virtualbox
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// StartNICTrace starts capturing the traffic of the n-th NIC to a pcap file,
+// which can be read with the pcap subpackage. For a running machine capture
+// starts immediately; otherwise it starts with the machine.
+func (m *Machine) StartNICTrace(n int, file string) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	if m.online() {
+		if err := vbm("controlvm", m.id(), fmt.Sprintf("nictracefile%d", n), abs); err != nil {
+			return err
+		}
+		return vbm("controlvm", m.id(), fmt.Sprintf("nictrace%d", n), "on")
+	}
+	return vbm("modifyvm", m.id(),
+		fmt.Sprintf("--nictracefile%d", n), abs,
+		fmt.Sprintf("--nictrace%d", n), "on")
+}
+
+// StopNICTrace stops capturing the traffic of the n-th NIC.
+func (m *Machine) StopNICTrace(n int) error {
+	if m.online() {
+		return vbm("controlvm", m.id(), fmt.Sprintf("nictrace%d", n), "off")
+	}
+	return vbm("modifyvm", m.id(), fmt.Sprintf("--nictrace%d", n), "off")
+}
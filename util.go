@@ -1,8 +1,12 @@
 package virtualbox
 
 import (
+	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // ParseIPv4Mask parses IPv4 netmask written in IP form (e.g. 255.255.255.0).
@@ -15,6 +19,14 @@ func ParseIPv4Mask(s string) net.IPMask {
 	return net.IPv4Mask(mask[12], mask[13], mask[14], mask[15])
 }
 
+var vmInfoUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\"`, `"`)
+
+// unescapeVMInfo undoes the escaping of backslashes, newlines and quotes in
+// string values of showvminfo --machinereadable.
+func unescapeVMInfo(s string) string {
+	return vmInfoUnescaper.Replace(s)
+}
+
 func Exists(name string) bool {
 	_, err := os.Stat(name)
 	return err == nil
@@ -28,3 +40,36 @@ func SystemProperties() (string, error) {
 	}
 	return out, nil
 }
+
+var (
+	versionOnce sync.Once
+	version     string
+	versionErr  error
+)
+
+// Version returns the version of the VBoxManage utility, e.g. "6.1.38r153438".
+// The result is cached for the lifetime of the process.
+func Version() (string, error) {
+	versionOnce.Do(func() {
+		out, err := vbmOut("--version")
+		if err != nil {
+			versionErr = err
+			return
+		}
+		version = strings.TrimSpace(out)
+	})
+	return version, versionErr
+}
+
+// majorVersion returns the major version number of VBoxManage.
+func majorVersion() (int, error) {
+	v, err := Version()
+	if err != nil {
+		return 0, err
+	}
+	i := strings.IndexByte(v, '.')
+	if i < 0 {
+		return 0, fmt.Errorf("unexpected VBoxManage version %q", v)
+	}
+	return strconv.Atoi(v[:i])
+}
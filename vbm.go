@@ -1,8 +1,10 @@
 package virtualbox
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
+	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -26,9 +28,9 @@ func init() {
 
 var (
 	reVMNameUUID      = regexp.MustCompile(`"(.+)" {([0-9a-f-]+)}`)
-	reVMInfoLine      = regexp.MustCompile(`(?:"(.+)"|(.+))=(?:"(.*)"|(.*))`)
+	reVMInfoLine      = regexp.MustCompile(`(?:"(.+)"|([^=]+))=(?:"(.*)"|(.*))`)
 	reColonLine       = regexp.MustCompile(`(.+):\s+(.*)`)
-	reMachineNotFound = regexp.MustCompile(`Could not find a registered machine named '(.+)'`)
+	reMachineNotFound = regexp.MustCompile(`Could not find a registered machine (?:named '(.+)'|with UUID \{(.+)\})`)
 )
 
 var (
@@ -37,6 +39,24 @@ var (
 	ErrVBMNotFound     = errors.New("VBoxManage not found")
 )
 
+// splitVMInfoLine splits a key="value" line of showvminfo --machinereadable.
+// Quotes around keys and values are optional.
+func splitVMInfoLine(line string) (key, val string, ok bool) {
+	res := reVMInfoLine.FindStringSubmatch(line)
+	if res == nil {
+		return "", "", false
+	}
+	key = res[1]
+	if key == "" {
+		key = res[2]
+	}
+	val = res[3]
+	if val == "" {
+		val = res[4]
+	}
+	return key, val, true
+}
+
 func vbm(args ...string) error {
 	cmd := exec.Command(VBM, args...)
 	if Verbose {
@@ -86,3 +106,55 @@ func vbmOutErr(args ...string) (string, string, error) {
 	}
 	return stdout.String(), stderr.String(), err
 }
+
+// vbmProgress runs VBoxManage and reports the percentages of the progress
+// indicator ("0%...10%...") it prints to progress, which may be nil.
+func vbmProgress(progress func(percent int), args ...string) error {
+	cmd := exec.Command(VBM, args...)
+	if Verbose {
+		log.Printf("executing: %v %v", VBM, strings.Join(args, " "))
+	}
+	r, w := io.Pipe()
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Start(); err != nil {
+		if ee, ok := err.(*exec.Error); ok && ee == exec.ErrNotFound {
+			return ErrVBMNotFound
+		}
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var out io.Reader = r
+		if Verbose {
+			out = io.TeeReader(r, os.Stderr)
+		}
+		scanProgress(out, progress)
+	}()
+	err := cmd.Wait()
+	w.Close()
+	<-done
+	return err
+}
+
+// scanProgress reads a progress indicator ("0%...10%...") from r and reports
+// each percentage to progress, which may be nil.
+func scanProgress(r io.Reader, progress func(percent int)) {
+	br := bufio.NewReader(r)
+	n, digits := 0, false
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			n, digits = n*10+int(c-'0'), true
+			continue
+		case c == '%' && digits && progress != nil:
+			progress(n)
+		}
+		n, digits = 0, false
+	}
+}
@@ -1,6 +1,10 @@
 package virtualbox
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -8,6 +12,19 @@ func init() {
 	Verbose = true
 }
 
+// useFakeVBM runs VBoxManage commands with the given shell script for the rest
+// of the test and returns the directory of the script.
+func useFakeVBM(t *testing.T, script string) string {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "VBoxManage"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	vbm := VBM
+	VBM = filepath.Join(dir, "VBoxManage")
+	t.Cleanup(func() { VBM = vbm })
+	return dir
+}
+
 func TestVBMOut(t *testing.T) {
 	b, err := vbmOut("list", "vms")
 	if err != nil {
@@ -15,3 +32,52 @@ func TestVBMOut(t *testing.T) {
 	}
 	t.Logf("%s", b)
 }
+
+func TestVMInfoLine(t *testing.T) {
+	for _, tc := range []struct {
+		line, key, val string
+	}{
+		{`memory=1024`, "memory", "1024"},
+		{`name="web"`, "name", "web"},
+		{`"SATA-0-0"="/vms/web.vdi"`, "SATA-0-0", "/vms/web.vdi"},
+		{`description="a=b"`, "description", "a=b"},
+		{`rec_screen_opts="vc_enabled=true,ac_enabled=false"`, "rec_screen_opts", "vc_enabled=true,ac_enabled=false"},
+	} {
+		res := reVMInfoLine.FindStringSubmatch(tc.line)
+		if res == nil {
+			t.Errorf("%s: no match", tc.line)
+			continue
+		}
+		key, val := res[1]+res[2], res[3]+res[4]
+		if key != tc.key || val != tc.val {
+			t.Errorf("%s: key, val = %q, %q, want %q, %q", tc.line, key, val, tc.key, tc.val)
+		}
+	}
+}
+
+func TestMachineNotFound(t *testing.T) {
+	for _, stderr := range []string{
+		"VBoxManage: error: Could not find a registered machine named 'web'\n",
+		"VBoxManage: error: Could not find a registered machine with UUID {0c5b3c9d-3f3e-4d1c-9a5e-0a3e7c1f2b4d}\n",
+	} {
+		if reMachineNotFound.FindString(stderr) == "" {
+			t.Errorf("%q not recognized as missing machine", stderr)
+		}
+	}
+	if reMachineNotFound.FindString("VBoxManage: error: Could not find file for the medium '/vms/web.vdi'\n") != "" {
+		t.Error("missing medium recognized as missing machine")
+	}
+}
+
+func TestScanProgress(t *testing.T) {
+	var got []int
+	scanProgress(strings.NewReader("0%...10%...20%...30%...40%...50%...60%...70%...80%...90%...100%\n"+
+		"Machine has been successfully moved into /vms/web\n"), func(percent int) {
+		got = append(got, percent)
+	})
+	want := []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %v, want %v", got, want)
+	}
+	scanProgress(strings.NewReader("0%...100%"), nil)
+}
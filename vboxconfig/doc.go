@@ -0,0 +1,11 @@
+/*
+Package vboxconfig reads VirtualBox settings files directly, without invoking
+VBoxManage or contacting VBoxSVC.
+
+Two kinds of files are supported: the machine settings file (.vbox) found at
+Machine.CfgFile, and the global VirtualBox.xml holding the machine and media
+registries, network services and global extra data. Settings are read as
+stored; values VirtualBox fills in at runtime, such as the machine state, are
+not available.
+*/
+package vboxconfig
@@ -0,0 +1,239 @@
+package vboxconfig
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// GlobalFile is the content of the VirtualBox.xml global settings file.
+type GlobalFile struct {
+	XMLName xml.Name `xml:"VirtualBox"`
+	Version string   `xml:"version,attr"`
+	Global  Global   `xml:"Global"`
+}
+
+// Global holds the global settings and registries of a VirtualBox host.
+type Global struct {
+	ExtraData        []ExtraData       `xml:"ExtraData>ExtraDataItem"`
+	Machines         []MachineEntry    `xml:"MachineRegistry>MachineEntry"`
+	MediaRegistry    MediaRegistry     `xml:"MediaRegistry"`
+	SystemProperties SystemProperties  `xml:"SystemProperties"`
+	DHCPServers      []DHCPServer      `xml:"NetserviceRegistry>DHCPServers>DHCPServer"`
+	NATNetworks      []NATNetwork      `xml:"NetserviceRegistry>NATNetworks>NATNetwork"`
+	HostOnlyNetworks []HostOnlyNetwork `xml:"HostOnlyNetworks>HostOnlyNetwork"` // VirtualBox 7.0+
+}
+
+// MachineEntry is a registered machine.
+type MachineEntry struct {
+	UUID string `xml:"uuid,attr"`
+	Src  string `xml:"src,attr"` // path of the .vbox file
+}
+
+// ID returns the machine UUID without braces.
+func (e MachineEntry) ID() string {
+	return trimUUID(e.UUID)
+}
+
+// MediaRegistry lists the known disk, DVD and floppy images.
+type MediaRegistry struct {
+	HardDisks    []Medium `xml:"HardDisks>HardDisk"`
+	DVDImages    []Medium `xml:"DVDImages>Image"`
+	FloppyImages []Medium `xml:"FloppyImages>Image"`
+}
+
+// Medium is an image in the media registry. Differencing disks are listed as
+// children of their parent.
+type Medium struct {
+	UUID     string   `xml:"uuid,attr"`
+	Location string   `xml:"location,attr"`
+	Format   string   `xml:"format,attr"`
+	Type     string   `xml:"type,attr"`
+	Children []Medium `xml:"HardDisk"`
+}
+
+// AllHardDisks returns all registered hard disks including differencing
+// disks, parents before children.
+func (r *MediaRegistry) AllHardDisks() []Medium {
+	var all []Medium
+	var walk func(ms []Medium)
+	walk = func(ms []Medium) {
+		for _, m := range ms {
+			all = append(all, m)
+			walk(m.Children)
+		}
+	}
+	walk(r.HardDisks)
+	return all
+}
+
+// SystemProperties holds host-wide defaults.
+type SystemProperties struct {
+	DefaultMachineFolder  string `xml:"defaultMachineFolder,attr"`
+	DefaultHardDiskFormat string `xml:"defaultHardDiskFormat,attr"`
+	VRDEAuthLibrary       string `xml:"VRDEAuthLibrary,attr"`
+	LogHistoryCount       uint   `xml:"LogHistoryCount,attr"`
+}
+
+// DHCPServer holds the settings of a DHCP server.
+type DHCPServer struct {
+	NetworkName string `xml:"networkName,attr"`
+	IPAddress   string `xml:"IPAddress,attr"`
+	NetworkMask string `xml:"networkMask,attr"`
+	LowerIP     string `xml:"lowerIP,attr"`
+	UpperIP     string `xml:"upperIP,attr"`
+	Enabled     bool   `xml:"enabled,attr"`
+}
+
+// NATNetwork holds the settings of a NAT network.
+type NATNetwork struct {
+	Name        string       `xml:"networkName,attr"`
+	Network     string       `xml:"network,attr"` // CIDR
+	Enabled     bool         `xml:"enabled,attr"`
+	DHCP        bool         `xml:"needDhcp,attr"`
+	IPv6        bool         `xml:"ipv6,attr"`
+	IPv6Prefix  string       `xml:"ipv6prefix,attr"`
+	PortForward []Forwarding `xml:"PortForwarding4>Forwarding"`
+}
+
+// HostOnlyNetwork holds the settings of a host-only network. Before
+// VirtualBox 7.0 host-only interfaces are configured through extra data, see
+// Global.HostOnlyInterfaces.
+type HostOnlyNetwork struct {
+	Name        string `xml:"name,attr"`
+	ID          string `xml:"id,attr"`
+	NetworkMask string `xml:"mask,attr"`
+	LowerIP     string `xml:"ipLower,attr"`
+	UpperIP     string `xml:"ipUpper,attr"`
+	Enabled     bool   `xml:"enabled,attr"`
+}
+
+// HostOnlyInterface holds the address configuration of a host-only interface
+// stored in the global extra data.
+type HostOnlyInterface struct {
+	Name          string
+	IPAddress     string
+	NetworkMask   string
+	IPv6Address   string
+	IPv6PrefixLen string
+}
+
+// HostOnlyInterfaces returns the host-only interface configuration stored in
+// the "HostOnly/<interface>/<setting>" extra data keys, sorted by name.
+func (g *Global) HostOnlyInterfaces() []HostOnlyInterface {
+	ifs := map[string]*HostOnlyInterface{}
+	for _, e := range g.ExtraData {
+		parts := strings.Split(e.Name, "/")
+		if len(parts) != 3 || parts[0] != "HostOnly" {
+			continue
+		}
+		hi := ifs[parts[1]]
+		if hi == nil {
+			hi = &HostOnlyInterface{Name: parts[1]}
+			ifs[parts[1]] = hi
+		}
+		switch parts[2] {
+		case "IPAddress":
+			hi.IPAddress = e.Value
+		case "IPNetMask":
+			hi.NetworkMask = e.Value
+		case "IPV6Address":
+			hi.IPv6Address = e.Value
+		case "IPV6NetMask":
+			hi.IPv6PrefixLen = e.Value
+		}
+	}
+	res := make([]HostOnlyInterface, 0, len(ifs))
+	for _, hi := range ifs {
+		res = append(res, *hi)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Extra returns the value of the extra data key, or "" if it is not set.
+func (g *Global) Extra(key string) string {
+	return extra(g.ExtraData, key)
+}
+
+// Extra returns the value of the extra data key, or "" if it is not set.
+func (m *Machine) Extra(key string) string {
+	return extra(m.ExtraData, key)
+}
+
+func extra(items []ExtraData, key string) string {
+	for _, e := range items {
+		if e.Name == key {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// ReadGlobal reads the VirtualBox.xml global settings file at path.
+func ReadGlobal(path string) (*GlobalFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseGlobal(f)
+}
+
+// ParseGlobal parses a VirtualBox.xml global settings file.
+func ParseGlobal(r io.Reader) (*GlobalFile, error) {
+	gf := &GlobalFile{}
+	if err := xml.NewDecoder(r).Decode(gf); err != nil {
+		return nil, err
+	}
+	return gf, nil
+}
+
+// GlobalPath returns the path of VirtualBox.xml for the current user: in
+// $VBOX_USER_HOME if set, otherwise in the platform default location.
+func GlobalPath() (string, error) {
+	if d := os.Getenv("VBOX_USER_HOME"); d != "" {
+		return filepath.Join(d, "VirtualBox.xml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	var dirs []string
+	switch runtime.GOOS {
+	case "darwin":
+		dirs = []string{filepath.Join(home, "Library", "VirtualBox")}
+	case "windows":
+		dirs = []string{filepath.Join(home, ".VirtualBox")}
+	default:
+		dirs = []string{filepath.Join(home, ".config", "VirtualBox"), filepath.Join(home, ".VirtualBox")}
+	}
+	for _, d := range dirs {
+		p := filepath.Join(d, "VirtualBox.xml")
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return filepath.Join(dirs[0], "VirtualBox.xml"), nil
+}
+
+// ReadMachines reads the settings files of all machines registered in the
+// global settings. Settings files that cannot be read are reported in the
+// returned map keyed by machine UUID instead of failing the whole read.
+func (g *Global) ReadMachines() ([]*MachineFile, map[string]error) {
+	ms := []*MachineFile{}
+	errs := map[string]error{}
+	for _, e := range g.Machines {
+		mf, err := ReadMachine(e.Src)
+		if err != nil {
+			errs[e.ID()] = err
+			continue
+		}
+		ms = append(ms, mf)
+	}
+	return ms, errs
+}
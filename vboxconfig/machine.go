@@ -0,0 +1,276 @@
+package vboxconfig
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+)
+
+// MachineFile is the content of a .vbox machine settings file.
+type MachineFile struct {
+	XMLName xml.Name `xml:"VirtualBox"`
+	Version string   `xml:"version,attr"` // settings format, e.g. "1.16-linux"
+	Machine Machine  `xml:"Machine"`
+}
+
+// Machine holds the settings of a machine.
+type Machine struct {
+	UUID            string        `xml:"uuid,attr"`
+	Name            string        `xml:"name,attr"`
+	OSType          string        `xml:"OSType,attr"`
+	StateFile       string        `xml:"stateFile,attr"`
+	SnapshotFolder  string        `xml:"snapshotFolder,attr"`
+	CurrentSnapshot string        `xml:"currentSnapshot,attr"`
+	LastStateChange string        `xml:"lastStateChange,attr"`
+	Description     string        `xml:"Description"`
+	Groups          []Group       `xml:"Groups>Group"`
+	MediaRegistry   MediaRegistry `xml:"MediaRegistry"`
+	ExtraData       []ExtraData   `xml:"ExtraData>ExtraDataItem"`
+	Hardware        Hardware      `xml:"Hardware"`
+	StorageCtls     []StorageCtl  `xml:"StorageControllers>StorageController"`
+}
+
+// Group is a machine group.
+type Group struct {
+	Name string `xml:"name,attr"`
+}
+
+// ExtraData is an extra data key/value pair.
+type ExtraData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Hardware holds the virtual hardware settings of a machine.
+type Hardware struct {
+	CPU           CPU           `xml:"CPU"`
+	Memory        Memory        `xml:"Memory"`
+	Firmware      Firmware      `xml:"Firmware"`
+	Boot          []BootOrder   `xml:"Boot>Order"`
+	Display       Display       `xml:"Display"`
+	RemoteDisplay RemoteDisplay `xml:"RemoteDisplay"`
+	Adapters      []Adapter     `xml:"Network>Adapter"`
+	StorageCtls   []StorageCtl  `xml:"StorageControllers>StorageController"` // VirtualBox 7.0+
+}
+
+// UnmarshalXML applies the defaults VirtualBox omits from the file, such as a
+// single CPU with hardware virtualization enabled and a single monitor.
+func (h *Hardware) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type hardware Hardware
+	v := hardware{
+		CPU: CPU{
+			Count:        1,
+			ExecutionCap: 100,
+			HWVirtEx:     Enabled{true},
+			NestedPaging: Enabled{true},
+			VPID:         Enabled{true},
+		},
+		Display: Display{Controller: "VBoxVGA", VRAMSize: 8, MonitorCount: 1},
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*h = Hardware(v)
+	return nil
+}
+
+// CPU holds the processor settings.
+type CPU struct {
+	Count        uint    `xml:"count,attr"`
+	ExecutionCap uint    `xml:"executionCap,attr"`
+	HotPlug      bool    `xml:"hotplug,attr"`
+	PAE          Enabled `xml:"PAE"`
+	LongMode     Enabled `xml:"LongMode"`
+	HWVirtEx     Enabled `xml:"HardwareVirtEx"`
+	NestedPaging Enabled `xml:"HardwareVirtExNestedPaging"`
+	LargePages   Enabled `xml:"HardwareVirtExLargePages"`
+	VPID         Enabled `xml:"HardwareVirtExVPID"`
+	NestedHWVirt Enabled `xml:"NestedHWVirt"`
+}
+
+// Enabled is an element carrying only an enabled attribute.
+type Enabled struct {
+	Enabled bool `xml:"enabled,attr"`
+}
+
+// Memory holds the main memory settings.
+type Memory struct {
+	RAMSize uint `xml:"RAMSize,attr"` // in MB
+}
+
+// Firmware holds the firmware settings.
+type Firmware struct {
+	Type string `xml:"type,attr"` // BIOS, EFI, EFI32, EFI64, EFIDUAL
+}
+
+// BootOrder is one slot of the boot order.
+type BootOrder struct {
+	Position uint   `xml:"position,attr"`
+	Device   string `xml:"device,attr"` // None, Floppy, DVD, HardDisk, Network
+}
+
+// Display holds the graphics settings.
+type Display struct {
+	Controller   string `xml:"controller,attr"`
+	VRAMSize     uint   `xml:"VRAMSize,attr"` // in MB
+	MonitorCount uint   `xml:"monitorCount,attr"`
+	Accelerate3D bool   `xml:"accelerate3D,attr"`
+	Accelerate2D bool   `xml:"accelerate2DVideo,attr"`
+}
+
+// RemoteDisplay holds the VRDE settings.
+type RemoteDisplay struct {
+	Enabled    bool       `xml:"enabled,attr"`
+	AuthType   string     `xml:"authType,attr"`
+	Properties []Property `xml:"VRDEProperties>Property"`
+}
+
+// Property is a name/value pair.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Adapter holds the settings of a network adapter. Slot is zero-based, so it
+// is one less than the NIC number used by VBoxManage.
+type Adapter struct {
+	Slot            uint       `xml:"slot,attr"`
+	Enabled         bool       `xml:"enabled,attr"`
+	MACAddress      string     `xml:"MACAddress,attr"` // without separators
+	Cable           bool       `xml:"cable,attr"`
+	Type            string     `xml:"type,attr"`
+	PromiscuousMode string     `xml:"promiscuousModePolicy,attr"`
+	BootPriority    uint       `xml:"bootPriority,attr"`
+	NAT             *NAT       `xml:"NAT"`
+	Bridged         *Interface `xml:"BridgedInterface"`
+	HostOnly        *Interface `xml:"HostOnlyInterface"`
+	Internal        *Interface `xml:"InternalNetwork"`
+	NATNetwork      *Interface `xml:"NATNetwork"`
+	Generic         *Generic   `xml:"GenericInterface"`
+}
+
+// UnmarshalXML applies the defaults VirtualBox omits from the file: the cable
+// is connected and promiscuous mode is denied.
+func (a *Adapter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type adapter Adapter
+	v := adapter{Cable: true, PromiscuousMode: "Deny"}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*a = Adapter(v)
+	return nil
+}
+
+// Attachment returns the network the adapter is attached to, using the
+// VBoxManage names (nat, bridged, hostonly, intnet, natnetwork, generic), or
+// "null" if it is not attached.
+func (a *Adapter) Attachment() string {
+	switch {
+	case a.NAT != nil:
+		return "nat"
+	case a.Bridged != nil:
+		return "bridged"
+	case a.HostOnly != nil:
+		return "hostonly"
+	case a.Internal != nil:
+		return "intnet"
+	case a.NATNetwork != nil:
+		return "natnetwork"
+	case a.Generic != nil:
+		return "generic"
+	}
+	return "null"
+}
+
+// NAT holds the settings of a NAT attachment.
+type NAT struct {
+	Forwarding []Forwarding `xml:"Forwarding"`
+}
+
+// Forwarding is a NAT port forwarding rule.
+type Forwarding struct {
+	Name      string `xml:"name,attr"`
+	Proto     uint   `xml:"proto,attr"` // 0 for UDP, 1 for TCP
+	HostIP    string `xml:"hostip,attr"`
+	HostPort  uint16 `xml:"hostport,attr"`
+	GuestIP   string `xml:"guestip,attr"`
+	GuestPort uint16 `xml:"guestport,attr"`
+}
+
+// Protocol returns "tcp" or "udp".
+func (f Forwarding) Protocol() string {
+	if f.Proto == 0 {
+		return "udp"
+	}
+	return "tcp"
+}
+
+// Interface names the host interface or network of an attachment.
+type Interface struct {
+	Name string `xml:"name,attr"`
+}
+
+// Generic holds the settings of a generic driver attachment.
+type Generic struct {
+	Driver     string     `xml:"driver,attr"`
+	Properties []Property `xml:"Property"`
+}
+
+// StorageCtl holds the settings of a storage controller.
+type StorageCtl struct {
+	Name        string           `xml:"name,attr"`
+	Type        string           `xml:"type,attr"` // e.g. AHCI, PIIX4, LsiLogic
+	PortCount   uint             `xml:"PortCount,attr"`
+	HostIOCache bool             `xml:"useHostIOCache,attr"`
+	Bootable    bool             `xml:"Bootable,attr"`
+	Devices     []AttachedDevice `xml:"AttachedDevice"`
+}
+
+// AttachedDevice is a drive attached to a storage controller.
+type AttachedDevice struct {
+	Type   string `xml:"type,attr"` // HardDisk, DVD, Floppy
+	Port   uint   `xml:"port,attr"`
+	Device uint   `xml:"device,attr"`
+	Image  *Image `xml:"Image"`
+}
+
+// Image references a medium by UUID.
+type Image struct {
+	UUID string `xml:"uuid,attr"`
+}
+
+// StorageControllers returns the storage controllers of the machine,
+// wherever the settings format version stores them.
+func (m *Machine) StorageControllers() []StorageCtl {
+	return append(append([]StorageCtl{}, m.StorageCtls...), m.Hardware.StorageCtls...)
+}
+
+// ID returns the machine UUID without braces.
+func (m *Machine) ID() string {
+	return trimUUID(m.UUID)
+}
+
+// ReadMachine reads the .vbox machine settings file at path.
+func ReadMachine(path string) (*MachineFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMachine(f)
+}
+
+// ParseMachine parses a .vbox machine settings file.
+func ParseMachine(r io.Reader) (*MachineFile, error) {
+	mf := &MachineFile{}
+	if err := xml.NewDecoder(r).Decode(mf); err != nil {
+		return nil, err
+	}
+	return mf, nil
+}
+
+// trimUUID strips the braces VirtualBox puts around UUIDs in settings files.
+func trimUUID(s string) string {
+	return strings.Trim(s, "{}")
+}
@@ -0,0 +1,122 @@
+package vboxconfig
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadMachine(t *testing.T) {
+	mf, err := ReadMachine(filepath.Join("testdata", "web.vbox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mf.Machine
+	if m.ID() != "0c5b3c9d-3f3e-4d1c-9a5e-0a3e7c1f2b4d" || m.Name != "web" || m.OSType != "Ubuntu_64" {
+		t.Errorf("machine = %+v", m)
+	}
+	if m.Description != "frontend\nbuilder" || len(m.Groups) != 1 || m.Groups[0].Name != "/proj/web" {
+		t.Errorf("description/groups = %q %+v", m.Description, m.Groups)
+	}
+	hw := m.Hardware
+	if hw.CPU.Count != 2 || !hw.CPU.LongMode.Enabled || hw.Memory.RAMSize != 2048 || hw.Firmware.Type != "EFI" {
+		t.Errorf("hardware = %+v", hw)
+	}
+	if len(hw.Boot) != 2 || hw.Boot[1].Device != "HardDisk" {
+		t.Errorf("boot = %+v", hw.Boot)
+	}
+	if len(hw.Adapters) != 2 || hw.Adapters[0].Attachment() != "nat" || hw.Adapters[1].Attachment() != "hostonly" {
+		t.Fatalf("adapters = %+v", hw.Adapters)
+	}
+	// The second adapter relies on the default cable setting.
+	if !hw.Adapters[0].Cable || !hw.Adapters[1].Cable || hw.Adapters[1].PromiscuousMode != "Deny" {
+		t.Errorf("adapter defaults = %+v", hw.Adapters)
+	}
+	fw := hw.Adapters[0].NAT.Forwarding
+	if len(fw) != 1 || fw[0].Protocol() != "tcp" || fw[0].HostPort != 2222 || fw[0].GuestPort != 22 {
+		t.Errorf("forwarding = %+v", fw)
+	}
+	ctls := m.StorageControllers()
+	if len(ctls) != 1 || ctls[0].Type != "AHCI" || len(ctls[0].Devices) != 1 || ctls[0].Devices[0].Image == nil {
+		t.Errorf("storage controllers = %+v", ctls)
+	}
+	if m.Extra("GUI/LastCloseAction") != "PowerOff" {
+		t.Errorf("extra data = %+v", m.ExtraData)
+	}
+}
+
+func TestReadGlobal(t *testing.T) {
+	gf, err := ReadGlobal(filepath.Join("testdata", "VirtualBox.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &gf.Global
+	if len(g.Machines) != 2 || g.Machines[0].ID() != "0c5b3c9d-3f3e-4d1c-9a5e-0a3e7c1f2b4d" {
+		t.Errorf("machines = %+v", g.Machines)
+	}
+	if disks := g.MediaRegistry.AllHardDisks(); len(disks) != 2 || disks[1].Location != "/vms/Snapshots/diff.vdi" {
+		t.Errorf("hard disks = %+v", disks)
+	}
+	if len(g.MediaRegistry.DVDImages) != 1 {
+		t.Errorf("dvd images = %+v", g.MediaRegistry.DVDImages)
+	}
+	if len(g.DHCPServers) != 1 || !g.DHCPServers[0].Enabled {
+		t.Errorf("dhcp servers = %+v", g.DHCPServers)
+	}
+	if len(g.NATNetworks) != 1 || g.NATNetworks[0].PortForward[0].HostPort != 8080 {
+		t.Errorf("nat networks = %+v", g.NATNetworks)
+	}
+	ifs := g.HostOnlyInterfaces()
+	if len(ifs) != 1 || ifs[0].Name != "vboxnet0" || ifs[0].IPAddress != "192.168.56.1" || ifs[0].NetworkMask != "255.255.255.0" {
+		t.Errorf("host-only interfaces = %+v", ifs)
+	}
+	if hn := g.HostOnlyNetworks; len(hn) != 1 || hn[0].NetworkMask != "255.255.255.0" || hn[0].LowerIP != "192.168.60.2" ||
+		hn[0].UpperIP != "192.168.60.199" || !hn[0].Enabled {
+		t.Errorf("host-only networks = %+v", hn)
+	}
+	if g.SystemProperties.DefaultMachineFolder != "/home/ci/VirtualBox VMs" {
+		t.Errorf("system properties = %+v", g.SystemProperties)
+	}
+
+	// Machine paths in the fixture are relative to testdata.
+	for i := range g.Machines {
+		g.Machines[i].Src = filepath.Join("testdata", g.Machines[i].Src)
+	}
+	ms, errs := g.ReadMachines()
+	if len(ms) != 1 || ms[0].Machine.Name != "web" {
+		t.Errorf("read machines = %+v", ms)
+	}
+	if len(errs) != 1 || errs["5e1f0000-0000-4000-8000-00000000dead"] == nil {
+		t.Errorf("read errors = %v", errs)
+	}
+}
+
+func TestParseMachineDefaults(t *testing.T) {
+	mf, err := ParseMachine(strings.NewReader(`<?xml version="1.0"?>
+<VirtualBox xmlns="http://www.virtualbox.org/" version="1.16-linux">
+  <Machine uuid="{5e1f0000-0000-4000-8000-000000000001}" name="tiny" OSType="Linux_64">
+    <Hardware>
+      <CPU>
+        <HardwareVirtExNestedPaging enabled="false"/>
+      </CPU>
+      <Network>
+        <Adapter slot="0" enabled="true" cable="false" type="82540EM"/>
+      </Network>
+    </Hardware>
+  </Machine>
+</VirtualBox>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hw := mf.Machine.Hardware
+	cpu := hw.CPU
+	if cpu.Count != 1 || cpu.ExecutionCap != 100 || !cpu.HWVirtEx.Enabled || cpu.NestedPaging.Enabled || !cpu.VPID.Enabled {
+		t.Errorf("cpu = %+v", cpu)
+	}
+	if hw.Display.MonitorCount != 1 || hw.Display.VRAMSize != 8 || hw.Display.Controller != "VBoxVGA" {
+		t.Errorf("display = %+v", hw.Display)
+	}
+	if len(hw.Adapters) != 1 || hw.Adapters[0].Cable {
+		t.Errorf("adapters = %+v", hw.Adapters)
+	}
+}
@@ -0,0 +1,167 @@
+package virtualbox
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrVRDEOfflineSetting = errors.New("VRDE setting can only be changed while the machine is not running")
+)
+
+// VRDE represents the VirtualBox Remote Desktop Extension server of a machine.
+type VRDE struct {
+	Enabled             bool
+	Ports               string // e.g. "3389" or "5000,5010-5012"
+	Address             string // empty to listen on all interfaces
+	AuthType            VRDEAuthType
+	MultiConn           bool
+	ReuseConn           bool
+	VideoChannel        bool
+	VideoChannelQuality uint // 10--100 percent, 0 to keep the current value
+	ExtPack             string
+	Properties          map[string]string // properties of the extension pack, e.g. "TCP/Ports"
+}
+
+// VRDEAuthType represents how VRDE clients are authenticated.
+type VRDEAuthType string
+
+const (
+	VRDEAuthNull     = VRDEAuthType("null")
+	VRDEAuthExternal = VRDEAuthType("external")
+	VRDEAuthGuest    = VRDEAuthType("guest")
+)
+
+// parse sets the VRDE field described by a showvminfo key. It returns false
+// if the key is not VRDE related.
+func (v *VRDE) parse(key, val string) bool {
+	switch key {
+	case "vrde":
+		v.Enabled = (val == "on")
+	case "vrdeport":
+		if v.Ports == "" && val != "-1" {
+			v.Ports = val
+		}
+	case "vrdeports":
+		v.Ports = val
+	case "vrdeaddress":
+		v.Address = val
+	case "vrdeauthtype":
+		v.AuthType = VRDEAuthType(val)
+	case "vrdemulticon":
+		v.MultiConn = (val == "on")
+	case "vrdereusecon":
+		v.ReuseConn = (val == "on")
+	case "vrdevideochannel":
+		v.VideoChannel = (val == "on")
+	case "vrdevideochannelquality":
+		if n, err := strconv.ParseUint(val, 10, 32); err == nil {
+			v.VideoChannelQuality = uint(n)
+		}
+	case "vrdeextpack":
+		v.ExtPack = val
+	default:
+		if !strings.HasPrefix(key, "vrdeproperty[") || !strings.HasSuffix(key, "]") {
+			return false
+		}
+		if val == "<not set>" {
+			return true
+		}
+		if v.Properties == nil {
+			v.Properties = map[string]string{}
+		}
+		v.Properties[key[len("vrdeproperty["):len(key)-1]] = val
+	}
+	return true
+}
+
+// sortedKeys returns the property names of the VRDE in a stable order.
+func (v *VRDE) sortedKeys() []string {
+	keys := make([]string, 0, len(v.Properties))
+	for k := range v.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetVRDE changes the VRDE server settings of the machine. While the machine
+// is running only Enabled, Ports, Address, VideoChannelQuality and Properties
+// can be changed; other differences yield ErrVRDEOfflineSetting.
+func (m *Machine) SetVRDE(v VRDE) error {
+	if m.online() {
+		if err := m.setVRDERunning(v); err != nil {
+			return err
+		}
+		return m.Refresh()
+	}
+
+	args := []string{"modifyvm", m.id(),
+		"--vrde", bool2string(v.Enabled),
+		"--vrdemulticon", bool2string(v.MultiConn),
+		"--vrdereusecon", bool2string(v.ReuseConn),
+		"--vrdevideochannel", bool2string(v.VideoChannel),
+		"--vrdeaddress", v.Address,
+	}
+	if v.Ports != "" {
+		args = append(args, "--vrdeport", v.Ports)
+	}
+	if v.AuthType != "" {
+		args = append(args, "--vrdeauthtype", string(v.AuthType))
+	}
+	if v.VideoChannelQuality > 0 {
+		args = append(args, "--vrdevideochannelquality", fmt.Sprintf("%d", v.VideoChannelQuality))
+	}
+	if v.ExtPack != "" {
+		args = append(args, "--vrdeextpack", v.ExtPack)
+	}
+	for _, k := range v.sortedKeys() {
+		args = append(args, "--vrdeproperty", fmt.Sprintf("%s=%s", k, v.Properties[k]))
+	}
+	if err := vbm(args...); err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+func (m *Machine) setVRDERunning(v VRDE) error {
+	cur := m.VRDE
+	if (v.AuthType != "" && v.AuthType != cur.AuthType) ||
+		v.MultiConn != cur.MultiConn ||
+		v.ReuseConn != cur.ReuseConn ||
+		v.VideoChannel != cur.VideoChannel ||
+		(v.ExtPack != "" && v.ExtPack != cur.ExtPack) {
+		return ErrVRDEOfflineSetting
+	}
+
+	if v.Ports != "" && v.Ports != cur.Ports {
+		if err := vbm("controlvm", m.id(), "vrdeport", v.Ports); err != nil {
+			return err
+		}
+	}
+	if v.Address != cur.Address {
+		if err := vbm("controlvm", m.id(), "vrdeproperty", "TCP/Address="+v.Address); err != nil {
+			return err
+		}
+	}
+	if v.VideoChannelQuality > 0 && v.VideoChannelQuality != cur.VideoChannelQuality {
+		if err := vbm("controlvm", m.id(), "vrdevideochannelquality", fmt.Sprintf("%d", v.VideoChannelQuality)); err != nil {
+			return err
+		}
+	}
+	for _, k := range v.sortedKeys() {
+		if val, ok := cur.Properties[k]; ok && val == v.Properties[k] {
+			continue
+		}
+		if err := vbm("controlvm", m.id(), "vrdeproperty", fmt.Sprintf("%s=%s", k, v.Properties[k])); err != nil {
+			return err
+		}
+	}
+	if v.Enabled != cur.Enabled {
+		return vbm("controlvm", m.id(), "vrde", bool2string(v.Enabled))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package virtualbox
+
+import (
+	"bufio"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scanVMInfo calls f for every key and value of showvminfo --machinereadable
+// output.
+func scanVMInfo(out string, f func(key, val string)) {
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		if key, val, ok := splitVMInfoLine(s.Text()); ok {
+			f(key, val)
+		}
+	}
+}
+
+func TestVRDEParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		out  string
+		want VRDE
+	}{
+		{"disabled", `memory=1024
+vrde="off"
+usb="off"
+`, VRDE{}},
+		{"configured", `vrde="on"
+vrdeport=3389
+vrdeports="3389"
+vrdeaddress="127.0.0.1"
+vrdeauthtype="external"
+vrdemulticon="on"
+vrdereusecon="off"
+vrdevideochannel="on"
+vrdevideochannelquality="75"
+vrdeproperty[TCP/Ports]="3389"
+vrdeproperty[TCP/Address]=<not set>
+vrdeproperty[VNCPassword]=<not set>
+`, VRDE{
+			Enabled: true, Ports: "3389", Address: "127.0.0.1", AuthType: VRDEAuthExternal,
+			MultiConn: true, VideoChannel: true, VideoChannelQuality: 75,
+			Properties: map[string]string{"TCP/Ports": "3389"},
+		}},
+		{"port not yet bound", `vrde="on"
+vrdeport=-1
+vrdeports="5000-5010"
+vrdeaddress=""
+vrdeauthtype="null"
+`, VRDE{Enabled: true, Ports: "5000-5010", AuthType: VRDEAuthNull}},
+		{"running", `vrde="on"
+vrdeport=5003
+vrdeports="5000-5010"
+`, VRDE{Enabled: true, Ports: "5000-5010"}},
+	} {
+		var v VRDE
+		scanVMInfo(tc.out, func(key, val string) {
+			v.parse(key, val)
+		})
+		if !reflect.DeepEqual(v, tc.want) {
+			t.Errorf("%s: VRDE = %+v, want %+v", tc.name, v, tc.want)
+		}
+	}
+	var v VRDE
+	if v.parse("memory", "1024") {
+		t.Error("parse accepted memory")
+	}
+}
+
+func TestSetVRDEAddress(t *testing.T) {
+	dir := useFakeVBM(t, `#!/bin/sh
+[ "$1" = showvminfo ] && exit 0
+printf '%s\n' "$@" > "$(dirname "$0")/args"
+`)
+	read := func() []string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(string(b), "\n")
+	}
+
+	m := &Machine{UUID: "uuid", State: Poweroff}
+	if err := m.SetVRDE(VRDE{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	args := read()
+	i := 0
+	for i < len(args)-1 && args[i] != "--vrdeaddress" {
+		i++
+	}
+	if i == len(args)-1 || args[i+1] != "" {
+		t.Errorf("modifyvm %q does not reset the address", args)
+	}
+
+	m = &Machine{UUID: "uuid", State: Running, VRDE: VRDE{Enabled: true, Address: "127.0.0.1"}}
+	if err := m.SetVRDE(VRDE{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if args := read(); len(args) < 4 || !reflect.DeepEqual(args[:4], []string{"controlvm", "uuid", "vrdeproperty", "TCP/Address="}) {
+		t.Errorf("controlvm %q does not reset the address", args)
+	}
+}